@@ -11,27 +11,46 @@ import (
 )
 
 func main() {
-	// Initialize database
-	db, err := database.Initialize(getDBURL())
-	if err != nil {
-		log.Fatal("Failed to initialize database:", err)
+	// Choose where games are stored
+	var store game.GameStore
+	switch getStore() {
+	case "postgres":
+		db, err := database.Initialize(getDBURL())
+		if err != nil {
+			log.Fatal("Failed to initialize database:", err)
+		}
+		defer db.Close()
+		store = game.NewPostgresStore(db)
+	case "memory":
+		store = game.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown game store %q", getStore())
 	}
-	defer db.Close()
 
 	// Create game manager
-	gameManager := game.NewGameManager(db)
+	gameManager := game.NewGameManager(store)
 
 	// Create WebSocket hub
 	hub := websocket.NewHub(gameManager)
 	go hub.Run()
 
+	// Bring back the games that were live when the server stopped
+	if err := gameManager.RestoreGames(); err != nil {
+		log.Printf("Failed to restore games: %v", err)
+	}
+
 	// Setup routes
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		websocket.HandleWebSocket(hub, w, r)
 	})
 
-	// Health check endpoint
+	// Health check endpoint, failing while games cannot be saved
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if err := gameManager.StoreError(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Store error: " + err.Error()))
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
@@ -63,6 +82,15 @@ func getPort() string {
 	return port
 }
 
+// getStore is where games are kept: "memory" (the default) or "postgres"
+func getStore() string {
+	store := os.Getenv("GAME_STORE")
+	if store == "" {
+		store = "memory"
+	}
+	return store
+}
+
 func getDBURL() string {
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
@@ -48,6 +48,21 @@ func createTables(db *sql.DB) error {
 			connected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 		
+		// Columns added to persist live games
+		`ALTER TABLE games
+			ADD COLUMN IF NOT EXISTS rule_set VARCHAR(32) NOT NULL DEFAULT 'free_play',
+			ADD COLUMN IF NOT EXISTS seed BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS shuffles INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS deck JSONB DEFAULT '[]',
+			ADD COLUMN IF NOT EXISTS shared_zone JSONB DEFAULT '[]',
+			ADD COLUMN IF NOT EXISTS state JSONB DEFAULT '{}'`,
+
+		`ALTER TABLE players
+			ADD COLUMN IF NOT EXISTS seat INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS chips INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS time_bank BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS resume_token VARCHAR(36) NOT NULL DEFAULT ''`,
+
 		`CREATE INDEX IF NOT EXISTS idx_players_game_id ON players(game_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_games_phase ON games(phase)`,
 		`CREATE INDEX IF NOT EXISTS idx_games_updated_at ON games(updated_at)`,
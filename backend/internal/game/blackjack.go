@@ -0,0 +1,552 @@
+package game
+
+// HandAction is a move on a blackjack hand
+type HandAction string
+
+const (
+	ActionHit    HandAction = "hit"
+	ActionStand  HandAction = "stand"
+	ActionDouble HandAction = "double"
+	ActionSplit  HandAction = "split"
+)
+
+// Blackjack hand results
+const (
+	ResultWin       = "win"
+	ResultLose      = "lose"
+	ResultPush      = "push"
+	ResultBlackjack = "blackjack"
+)
+
+const (
+	blackjackDecks         = 6
+	blackjackStartingChips = 1000
+	blackjackMinBet        = 10
+	blackjackMaxHands      = 4
+	blackjackDealerStands  = 17
+	// blackjackMaxCards is the most cards a hand can hold without busting
+	blackjackMaxCards = 11
+)
+
+// BlackjackHand is one of a player's hands; splitting creates more
+type BlackjackHand struct {
+	Cards   []Card `json:"cards"`
+	Bet     int    `json:"bet"`
+	Done    bool   `json:"done"`
+	Doubled bool   `json:"doubled"`
+	Split   bool   `json:"split"`
+	Result  string `json:"result,omitempty"`
+	Payout  int    `json:"payout,omitempty"`
+}
+
+// BlackjackState is the table state of a blackjack game. Game.Deck is the
+// shoe, the players' hands and the dealer's cards are kept here and go to
+// PlayedCards when the round is settled.
+type BlackjackState struct {
+	Round      int                         `json:"round"`
+	Decks      int                         `json:"decks"`
+	Dealer     []Card                      `json:"dealer"`
+	Hands      map[string][]*BlackjackHand `json:"hands"`
+	ActiveHand int                         `json:"activeHand"`
+	Bets       map[string]int              `json:"bets"`
+	BoughtIn   map[string]bool             `json:"boughtIn"`
+	Settled    bool                        `json:"settled"`
+}
+
+// blackjackView is the blackjack state sent to clients. The dealer's hole
+// card stays hidden until the dealer plays.
+type blackjackView struct {
+	Round        int                         `json:"round"`
+	Decks        int                         `json:"decks"`
+	Dealer       []Card                      `json:"dealer"`
+	DealerHidden int                         `json:"dealerHidden"`
+	DealerTotal  int                         `json:"dealerTotal"`
+	Hands        map[string][]*BlackjackHand `json:"hands"`
+	ActiveHand   int                         `json:"activeHand"`
+	Bets         map[string]int              `json:"bets"`
+	Settled      bool                        `json:"settled"`
+}
+
+type PlaceBetData struct {
+	Amount int `json:"amount"`
+}
+
+type DealerPlayedData struct {
+	Cards []Card `json:"cards"`
+	Total int    `json:"total"`
+}
+
+type HandSettledData struct {
+	PlayerID  string `json:"playerId"`
+	HandIndex int    `json:"handIndex"`
+	Result    string `json:"result"`
+	Payout    int    `json:"payout"`
+}
+
+type RoundSettledData struct {
+	Hands []HandSettledData `json:"hands"`
+}
+
+// Blackjack is played against a dealer run by the server, so a single
+// player can play alone. Every deal_cards starts a round from a multi-deck
+// shoe, players act on their hands in turn and the dealer then draws to 17
+// before the bets are paid out.
+type Blackjack struct{}
+
+func (Blackjack) Name() string { return "blackjack" }
+
+func (Blackjack) MinPlayers() int { return 1 }
+
+func (Blackjack) MaxPlayers() int { return 7 }
+
+// Deal takes the bets and deals two cards to each player and the dealer
+func (Blackjack) Deal(game *Game) error {
+	state := blackjackTable(game)
+	if !state.Settled {
+		return ErrRoundInProgress
+	}
+
+	// The first round is dealt from a fresh shoe, which is replaced once
+	// three quarters of it have been dealt or it could run out during the
+	// round
+	if state.Round == 0 || len(game.Deck) < blackjackReshuffleAt(game, state) {
+		blackjackShoe(game, state)
+	}
+
+	state.Hands = make(map[string][]*BlackjackHand)
+	for i := range game.Players {
+		player := &game.Players[i]
+		bet := state.Bets[player.ID]
+		if bet == 0 {
+			bet = blackjackMinBet
+		}
+		if bet > player.Chips {
+			bet = player.Chips
+		}
+		if bet < blackjackMinBet {
+			continue
+		}
+		player.Chips -= bet
+		state.Hands[player.ID] = []*BlackjackHand{{Cards: make([]Card, 0, 2), Bet: bet}}
+	}
+
+	if len(state.Hands) == 0 {
+		return errorOf(ErrNotEnoughChips, "no player can cover a bet")
+	}
+
+	state.Round++
+	state.Settled = false
+	state.ActiveHand = 0
+	state.Dealer = make([]Card, 0, 2)
+
+	// Two rounds of one card each, the dealer's second card face down
+	for round := 0; round < 2; round++ {
+		for _, player := range game.Players {
+			if hands, seated := state.Hands[player.ID]; seated {
+				hands[0].Cards = append(hands[0].Cards, blackjackDraw(game))
+			}
+		}
+		state.Dealer = append(state.Dealer, blackjackDraw(game))
+	}
+
+	// Naturals need no further action
+	for _, hands := range state.Hands {
+		if blackjackIsNatural(hands[0]) {
+			hands[0].Done = true
+		}
+	}
+
+	game.Phase = PhasePlaying
+
+	// The dealer peeks for blackjack and settles at once
+	if total, _ := blackjackTotal(state.Dealer); total == 21 {
+		blackjackSettle(game)
+		return nil
+	}
+
+	setCurrentPlayer(game, "")
+	blackjackAdvance(game, -1)
+	return nil
+}
+
+// CheckOptions rejects jokers, which have no value in blackjack
+func (Blackjack) CheckOptions(options GameOptions) error {
+	if options.Jokers {
+		return errorOf(ErrInvalidOptions, "blackjack is played without jokers")
+	}
+	return nil
+}
+
+// ValidatePlay rejects card plays, blackjack is played with hand actions
+func (Blackjack) ValidatePlay(game *Game, player *Player, play Play) error {
+	return errorOf(ErrIllegalCard, "cards cannot be played in blackjack")
+}
+
+func (Blackjack) ApplyPlay(game *Game, player *Player, play Play) {}
+
+func (Blackjack) AdvanceTurn(game *Game) {}
+
+// IsOver reports whether no player can cover the minimum bet any more
+func (Blackjack) IsOver(game *Game) bool {
+	state := game.Blackjack
+	if state == nil || !state.Settled {
+		return false
+	}
+
+	for _, player := range game.Players {
+		if player.Chips >= blackjackMinBet {
+			return false
+		}
+	}
+	return true
+}
+
+// Score does nothing, the players' chip stacks are their score
+func (Blackjack) Score(game *Game) {}
+
+// PlaceBet sets the bet a player makes on the next round
+func (Blackjack) PlaceBet(game *Game, player *Player, amount int) error {
+	state := blackjackTable(game)
+	if !state.Settled {
+		return errorOf(ErrRoundInProgress, "bets can only be placed between rounds")
+	}
+
+	if amount < blackjackMinBet {
+		return errorOf(ErrInvalidBet, "bet is below the table minimum")
+	}
+
+	if amount > player.Chips {
+		return ErrNotEnoughChips
+	}
+
+	state.Bets[player.ID] = amount
+	return nil
+}
+
+// HandAction plays the current player's active hand
+func (Blackjack) HandAction(game *Game, player *Player, action HandAction) error {
+	state := game.Blackjack
+	if state == nil || state.Settled {
+		return errorOf(ErrNotDealt, "no round in progress")
+	}
+
+	hands := state.Hands[player.ID]
+	if state.ActiveHand >= len(hands) {
+		return errorOf(ErrIllegalMove, "no hand to play")
+	}
+	hand := hands[state.ActiveHand]
+
+	switch action {
+	case ActionHit:
+		if err := blackjackReady(game, 1); err != nil {
+			return err
+		}
+		hand.Cards = append(hand.Cards, blackjackDraw(game))
+		if total, _ := blackjackTotal(hand.Cards); total >= 21 {
+			hand.Done = true
+		}
+
+	case ActionStand:
+		hand.Done = true
+
+	case ActionDouble:
+		if len(hand.Cards) != 2 {
+			return errorOf(ErrIllegalMove, "can only double on the first two cards")
+		}
+		if player.Chips < hand.Bet {
+			return ErrNotEnoughChips
+		}
+		if err := blackjackReady(game, 1); err != nil {
+			return err
+		}
+		player.Chips -= hand.Bet
+		hand.Bet *= 2
+		hand.Doubled = true
+		hand.Cards = append(hand.Cards, blackjackDraw(game))
+		hand.Done = true
+
+	case ActionSplit:
+		if len(hand.Cards) != 2 || hand.Cards[0].Rank != hand.Cards[1].Rank {
+			return errorOf(ErrIllegalMove, "can only split a pair")
+		}
+		if len(hands) >= blackjackMaxHands {
+			return errorOf(ErrIllegalMove, "cannot split any further")
+		}
+		if player.Chips < hand.Bet {
+			return ErrNotEnoughChips
+		}
+		if err := blackjackReady(game, 2); err != nil {
+			return err
+		}
+		player.Chips -= hand.Bet
+
+		splitHand := &BlackjackHand{Cards: []Card{hand.Cards[1]}, Bet: hand.Bet, Split: true}
+		hand.Cards = []Card{hand.Cards[0], blackjackDraw(game)}
+		hand.Split = true
+		splitHand.Cards = append(splitHand.Cards, blackjackDraw(game))
+
+		// Split aces get a single card each
+		if hand.Cards[0].Rank == "A" {
+			hand.Done = true
+			splitHand.Done = true
+		}
+
+		hands = append(hands[:state.ActiveHand+1], append([]*BlackjackHand{splitHand}, hands[state.ActiveHand+1:]...)...)
+		state.Hands[player.ID] = hands
+
+	default:
+		return errorOf(ErrIllegalMove, "unknown hand action")
+	}
+
+	blackjackAdvance(game, findPlayer(game, player.ID))
+	return nil
+}
+
+// TimeoutTurn stands on the hand of a player who runs out of time, moving
+// play on when they have no hand left to play
+func (Blackjack) TimeoutTurn(game *Game, player *Player) error {
+	state := game.Blackjack
+	if state != nil && !state.Settled && state.ActiveHand >= len(state.Hands[player.ID]) {
+		blackjackAdvance(game, findPlayer(game, player.ID))
+		return nil
+	}
+	return Blackjack{}.HandAction(game, player, ActionStand)
+}
+
+// Forfeit stands on the hand of a player whose time bank has run out
+func (Blackjack) Forfeit(game *Game, player *Player) {
+	Blackjack{}.HandAction(game, player, ActionStand)
+}
+
+// Leave gives up the hands of a player who leaves during a round, losing
+// their bets, and moves play on if it was their turn
+func (Blackjack) Leave(game *Game, player *Player) {
+	state := game.Blackjack
+	if state == nil || state.Settled {
+		return
+	}
+
+	for _, hand := range state.Hands[player.ID] {
+		game.PlayedCards = append(game.PlayedCards, hand.Cards...)
+	}
+	delete(state.Hands, player.ID)
+
+	if game.CurrentPlayer == player.ID {
+		blackjackAdvance(game, findPlayer(game, player.ID))
+	}
+}
+
+// ViewState exposes the hands on the table and the dealer's up card
+func (Blackjack) ViewState(game *Game, viewerID string) interface{} {
+	state := game.Blackjack
+	if state == nil {
+		return nil
+	}
+
+	view := blackjackView{
+		Round:      state.Round,
+		Decks:      state.Decks,
+		Dealer:     state.Dealer,
+		Hands:      state.Hands,
+		ActiveHand: state.ActiveHand,
+		Bets:       state.Bets,
+		Settled:    state.Settled,
+	}
+
+	if !state.Settled && len(state.Dealer) > 1 {
+		view.Dealer = state.Dealer[:1]
+		view.DealerHidden = len(state.Dealer) - 1
+	}
+	view.DealerTotal, _ = blackjackTotal(view.Dealer)
+
+	return view
+}
+
+// blackjackTable returns the blackjack state of a game, setting up the
+// table on first use with a six deck shoe unless the table options say
+// otherwise. New players buy in for the starting stack.
+func blackjackTable(game *Game) *BlackjackState {
+	state := game.Blackjack
+	if state == nil {
+		decks := game.Options.Decks
+		if decks == 0 {
+			decks = blackjackDecks
+		}
+		state = &BlackjackState{
+			Decks:    decks,
+			Bets:     make(map[string]int),
+			BoughtIn: make(map[string]bool),
+			Settled:  true,
+		}
+		game.Blackjack = state
+	}
+
+	for i := range game.Players {
+		if !state.BoughtIn[game.Players[i].ID] {
+			state.BoughtIn[game.Players[i].ID] = true
+			game.Players[i].Chips = blackjackStartingChips
+		}
+	}
+
+	return state
+}
+
+// blackjackReshuffleAt is the fewest cards a round can start with: a
+// quarter of the shoe, and enough for every seat and the dealer to draw
+// the most cards a hand can hold
+func blackjackReshuffleAt(game *Game, state *BlackjackState) int {
+	return max(state.Decks*52/4, (len(game.Players)+1)*blackjackMaxCards)
+}
+
+// blackjackShoe replaces the shoe with freshly shuffled decks
+func blackjackShoe(game *Game, state *BlackjackState) {
+	newDeck(game, DeckBuilder{Spec: FrenchDeck, Decks: state.Decks}.Build())
+	shuffleDeck(game, game.Deck)
+}
+
+// blackjackAdvance moves to the next hand still to be played, starting with
+// the given player's, and lets the dealer play once every hand is done
+func blackjackAdvance(game *Game, fromIndex int) {
+	state := game.Blackjack
+
+	if fromIndex >= 0 {
+		for i, hand := range state.Hands[game.Players[fromIndex].ID] {
+			if !hand.Done {
+				state.ActiveHand = i
+				return
+			}
+		}
+	}
+
+	for index := fromIndex + 1; index < len(game.Players); index++ {
+		for i, hand := range state.Hands[game.Players[index].ID] {
+			if !hand.Done {
+				state.ActiveHand = i
+				setCurrentPlayer(game, game.Players[index].ID)
+				return
+			}
+		}
+	}
+
+	// Every hand has been played, the dealer draws to 17 unless every
+	// player has busted
+	live := false
+	for _, hands := range state.Hands {
+		for _, hand := range hands {
+			if total, _ := blackjackTotal(hand.Cards); total <= 21 {
+				live = true
+			}
+		}
+	}
+
+	for live {
+		total, _ := blackjackTotal(state.Dealer)
+		if total >= blackjackDealerStands || blackjackReady(game, 1) != nil {
+			break
+		}
+		state.Dealer = append(state.Dealer, blackjackDraw(game))
+	}
+
+	total, _ := blackjackTotal(state.Dealer)
+	game.emit(MsgDealerPlayed, DealerPlayedData{Cards: state.Dealer, Total: total})
+	blackjackSettle(game)
+}
+
+// blackjackSettle pays out every hand against the dealer and clears the
+// table
+func blackjackSettle(game *Game) {
+	state := game.Blackjack
+	dealerTotal, _ := blackjackTotal(state.Dealer)
+	dealerNatural := dealerTotal == 21 && len(state.Dealer) == 2
+
+	settled := make([]HandSettledData, 0)
+	for i := range game.Players {
+		player := &game.Players[i]
+		for handIndex, hand := range state.Hands[player.ID] {
+			total, _ := blackjackTotal(hand.Cards)
+			natural := blackjackIsNatural(hand)
+
+			switch {
+			case natural && !dealerNatural:
+				hand.Result = ResultBlackjack
+				hand.Payout = hand.Bet + hand.Bet*3/2
+			case total > 21:
+				hand.Result = ResultLose
+			case dealerNatural && !natural:
+				hand.Result = ResultLose
+			case dealerTotal > 21 || total > dealerTotal:
+				hand.Result = ResultWin
+				hand.Payout = hand.Bet * 2
+			case total == dealerTotal:
+				hand.Result = ResultPush
+				hand.Payout = hand.Bet
+			default:
+				hand.Result = ResultLose
+			}
+
+			player.Chips += hand.Payout
+			game.PlayedCards = append(game.PlayedCards, hand.Cards...)
+			settled = append(settled, HandSettledData{
+				PlayerID:  player.ID,
+				HandIndex: handIndex,
+				Result:    hand.Result,
+				Payout:    hand.Payout,
+			})
+		}
+	}
+
+	game.PlayedCards = append(game.PlayedCards, state.Dealer...)
+	state.Settled = true
+	setCurrentPlayer(game, "")
+	game.emit(MsgRoundSettled, RoundSettledData{Hands: settled})
+}
+
+// blackjackReady makes sure the shoe holds the cards a move needs,
+// shuffling the discards back in when it runs short. Splits can take more
+// cards than a round is dealt with, so a small shoe can run out.
+func blackjackReady(game *Game, count int) error {
+	if len(game.Deck) < count {
+		refillDeck(game)
+	}
+	if len(game.Deck) < count {
+		return errorOf(ErrDeckEmpty, "the shoe has run out")
+	}
+	return nil
+}
+
+// blackjackDraw takes the next card from the shoe, which blackjackReady
+// must have checked holds it
+func blackjackDraw(game *Game) Card {
+	card := game.Deck[0]
+	game.Deck = game.Deck[1:]
+	return card
+}
+
+// blackjackTotal returns the best total of a hand and whether it is soft,
+// counting an ace as 11 when that does not bust
+func blackjackTotal(cards []Card) (int, bool) {
+	total, aces := 0, 0
+	for _, card := range cards {
+		switch {
+		case card.Rank == "A":
+			total++
+			aces++
+		case card.Value > 10:
+			total += 10
+		default:
+			total += card.Value
+		}
+	}
+
+	if aces > 0 && total+10 <= 21 {
+		return total + 10, true
+	}
+	return total, false
+}
+
+// blackjackIsNatural reports whether a hand is a two card 21 that did not
+// come from a split
+func blackjackIsNatural(hand *BlackjackHand) bool {
+	total, _ := blackjackTotal(hand.Cards)
+	return total == 21 && len(hand.Cards) == 2 && !hand.Split
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBlackjackReady(t *testing.T) {
+	tests := []struct {
+		name    string
+		deck    int
+		played  int
+		count   int
+		wantErr bool
+	}{
+		{"shoe holds the cards", 5, 0, 2, false},
+		{"discards refill the shoe", 1, 10, 2, false},
+		{"shoe and discards are empty", 0, 0, 1, true},
+		{"shoe and discards run short", 1, 0, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards := createDeck()
+			game := &Game{Deck: cards[:tt.deck], PlayedCards: cards[tt.deck : tt.deck+tt.played]}
+
+			err := blackjackReady(game, tt.count)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDeckEmpty) {
+					t.Fatalf("got %v, want %v", err, ErrDeckEmpty)
+				}
+				return
+			}
+			if err != nil || len(game.Deck) < tt.count {
+				t.Fatalf("got %v with %d cards in the shoe", err, len(game.Deck))
+			}
+		})
+	}
+}
+
+// TestBlackjackSmallShoe plays a full table from a single deck, splitting
+// and hitting every hand as far as it goes, so splits run the shoe dry
+func TestBlackjackSmallShoe(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		gm := NewGameManager(nil)
+		game, err := gm.CreateGameWithSeed(GameOptions{RuleSet: "blackjack", Decks: 1}, seed)
+		if err != nil {
+			t.Fatalf("creating game: %v", err)
+		}
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+			if _, _, err := gm.JoinGame(game.ID, name, "", "", SeatRequest{}); err != nil {
+				t.Fatalf("joining: %v", err)
+			}
+		}
+
+		for round := 0; round < 10 && game.Phase != PhaseFinished; round++ {
+			if _, err := gm.DealCards(requestFor(game, game.Players[0].ID)); err != nil {
+				t.Fatalf("seed %d round %d: dealing: %v", seed, round, err)
+			}
+
+			for moves := 0; !game.Blackjack.Settled; moves++ {
+				if moves > 200 {
+					t.Fatalf("seed %d round %d: round never settled", seed, round)
+				}
+				request := requestFor(game, game.CurrentPlayer)
+				if _, err := gm.HandAction(request, ActionSplit); err == nil {
+					continue
+				}
+				if _, err := gm.HandAction(request, ActionHit); err == nil {
+					continue
+				}
+				if _, err := gm.HandAction(request, ActionStand); err != nil {
+					t.Fatalf("seed %d round %d: standing: %v", seed, round, err)
+				}
+			}
+		}
+	}
+}
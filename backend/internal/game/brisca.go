@@ -0,0 +1,131 @@
+package game
+
+const briscaHandSize = 3
+
+// BriscaState is the table state of a Brisca game. The trump card is
+// turned up under the deck and is the last card to be drawn.
+type BriscaState struct {
+	Trump        Card     `json:"trump"`
+	TrickPlayers []string `json:"trickPlayers"`
+}
+
+// briscaView is the Brisca state sent to clients
+type briscaView struct {
+	Trump Card        `json:"trump"`
+	Trick []TrickCard `json:"trick"`
+}
+
+// Brisca is played with the Spanish deck. Players hold three cards, there
+// is no obligation to follow suit, the highest trump or else the highest
+// card of the suit led takes the trick, and everyone draws back up to
+// three cards after each trick. Trick points are added to the players'
+// scores and the highest score wins once all cards are played.
+type Brisca struct{}
+
+func (Brisca) Name() string { return "brisca" }
+
+func (Brisca) MinPlayers() int { return 2 }
+
+func (Brisca) MaxPlayers() int { return 4 }
+
+// Deal gives three cards to each player and turns up the trump
+func (Brisca) Deal(game *Game) error {
+	if game.Brisca != nil && game.Phase != PhaseFinished {
+		return errorOf(ErrRoundInProgress, "a game is already in progress")
+	}
+
+	newDeck(game, newSpanishDeck(len(game.Players)))
+
+	for i := range game.Players {
+		hand, err := dealCards(game, briscaHandSize)
+		if err != nil {
+			return err
+		}
+		game.Players[i].Hand = hand
+		game.Players[i].Score = 0
+	}
+
+	// The trump goes face up under the deck
+	trump := game.Deck[0]
+	game.Deck = append(game.Deck[1:], trump)
+	game.Brisca = &BriscaState{Trump: trump, TrickPlayers: make([]string, 0)}
+
+	game.Phase = PhasePlaying
+	if findPlayer(game, game.CurrentPlayer) == -1 {
+		setCurrentPlayer(game, game.Players[0].ID)
+	}
+	return nil
+}
+
+// ValidatePlay accepts any card once the cards have been dealt
+func (Brisca) ValidatePlay(game *Game, player *Player, play Play) error {
+	if game.Brisca == nil {
+		return ErrNotDealt
+	}
+	return nil
+}
+
+// ApplyPlay adds the card to the current trick
+func (Brisca) ApplyPlay(game *Game, player *Player, play Play) {
+	game.SharedZone = append(game.SharedZone, play.Card)
+	game.Brisca.TrickPlayers = append(game.Brisca.TrickPlayers, player.ID)
+}
+
+// AdvanceTurn passes the turn and resolves the trick once everyone has
+// played. The winner scores the trick and draws first.
+func (Brisca) AdvanceTurn(game *Game) {
+	state := game.Brisca
+	if len(game.SharedZone) < len(game.Players) {
+		nextPlayer(game)
+		return
+	}
+
+	winner := spanishTrickWinner(game.SharedZone, state.Trump.Suit)
+	points := 0
+	for _, card := range game.SharedZone {
+		points += spanishCardPoints(card)
+	}
+
+	winnerID := takeTrick(game, state.TrickPlayers, winner, points)
+	state.TrickPlayers = make([]string, 0)
+
+	winnerIndex := findPlayer(game, winnerID)
+	game.Players[winnerIndex].Score += points
+
+	// Draw back up, starting with the winner
+	for offset := 0; offset < len(game.Players) && len(game.Deck) > 0; offset++ {
+		player := &game.Players[(winnerIndex+offset)%len(game.Players)]
+		player.Hand = append(player.Hand, game.Deck[0])
+		game.Deck = game.Deck[1:]
+	}
+}
+
+// IsOver reports whether every card has been played
+func (Brisca) IsOver(game *Game) bool {
+	if game.Brisca == nil || len(game.Deck) > 0 || len(game.SharedZone) > 0 {
+		return false
+	}
+
+	for _, player := range game.Players {
+		if len(player.Hand) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Score does nothing as trick points are scored as tricks are taken
+func (Brisca) Score(game *Game) {}
+
+// ViewState exposes the trump and the trick in play
+func (Brisca) ViewState(game *Game, viewerID string) interface{} {
+	state := game.Brisca
+	if state == nil {
+		return nil
+	}
+
+	return briscaView{
+		Trump: state.Trump,
+		Trick: trickCards(game, state.TrickPlayers),
+	}
+}
@@ -0,0 +1,78 @@
+package game
+
+import "fmt"
+
+// cardZones lists the places the cards of a game are kept, by name. The
+// trumps of the Spanish games, the gin knocker's deadwood and the hands of
+// a settled blackjack round are copies of cards kept elsewhere and are not
+// zones.
+func cardZones(game *Game) map[string][]Card {
+	zones := map[string][]Card{
+		"deck":   game.Deck,
+		"played": game.PlayedCards,
+		"shared": game.SharedZone,
+	}
+
+	for _, player := range game.Players {
+		zones["hand "+player.ID] = player.Hand
+	}
+	for _, meld := range game.Melds {
+		zones["meld "+meld.ID] = meld.Cards
+	}
+
+	if game.Hearts != nil {
+		for playerID, cards := range game.Hearts.Passed {
+			zones["passed "+playerID] = cards
+		}
+	}
+
+	if game.Blackjack != nil && !game.Blackjack.Settled {
+		zones["dealer"] = game.Blackjack.Dealer
+		for playerID, hands := range game.Blackjack.Hands {
+			for i, hand := range hands {
+				zones[fmt.Sprintf("hand %s/%d", playerID, i+1)] = hand.Cards
+			}
+		}
+	}
+	return zones
+}
+
+// newDeck replaces every card of a game with a new deck, emptying the
+// hands, the table and the discard pile. The game is expected to hold the
+// deck's cards from then on.
+func newDeck(game *Game, cards []Card) {
+	for i := range game.Players {
+		game.Players[i].Hand = make([]Card, 0)
+	}
+	game.Deck = cards
+	game.PlayedCards = make([]Card, 0)
+	game.SharedZone = make([]Card, 0)
+	game.Melds = nil
+	game.cardCount = len(cards)
+}
+
+// checkCards verifies that no card has been lost, copied or made up: every
+// card is in exactly one zone and the game holds as many cards as its
+// deck had. Games that have not been counted yet, like restored ones, are
+// counted as they are.
+func checkCards(game *Game) error {
+	seen := make(map[string]string)
+	count := 0
+	for zone, cards := range cardZones(game) {
+		for _, card := range cards {
+			if other, found := seen[card.ID]; found {
+				return fmt.Errorf("card %s is in both %s and %s", card.ID, other, zone)
+			}
+			seen[card.ID] = zone
+			count++
+		}
+	}
+
+	if game.cardCount == 0 {
+		game.cardCount = count
+	}
+	if count != game.cardCount {
+		return fmt.Errorf("game holds %d cards but %d were dealt", count, game.cardCount)
+	}
+	return nil
+}
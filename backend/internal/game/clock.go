@@ -0,0 +1,120 @@
+package game
+
+import "time"
+
+type TimeForfeitData struct {
+	PlayerID string `json:"playerId"`
+}
+
+// runClock charges the time since the last change to the player whose
+// clock was running, adding the increment once their turn has passed, and
+// starts the clock of the current player. Clocks only run on tables with a
+// time bank and stop while the game is paused.
+func (gm *GameManager) runClock(game *Game, now time.Time) {
+	stopClock(game, now)
+
+	if game.Options.TimeBank == 0 || game.Phase != PhasePlaying || game.CurrentPlayer == "" {
+		return
+	}
+
+	playerIndex := findPlayer(game, game.CurrentPlayer)
+	if playerIndex == -1 {
+		return
+	}
+
+	game.clockPlayer = game.CurrentPlayer
+	game.clockTurn = game.turn
+	game.clockStarted = now
+
+	gameID, started := game.ID, now
+	game.clockTimer = time.AfterFunc(game.Players[playerIndex].TimeBank, func() {
+		gm.expireClock(gameID, started)
+	})
+}
+
+// stopClock charges the running clock up to now and stops it
+func stopClock(game *Game, now time.Time) {
+	if game.clockTimer != nil {
+		game.clockTimer.Stop()
+		game.clockTimer = nil
+	}
+
+	if game.clockPlayer == "" {
+		return
+	}
+
+	if playerIndex := findPlayer(game, game.clockPlayer); playerIndex != -1 {
+		player := &game.Players[playerIndex]
+		player.TimeBank -= now.Sub(game.clockStarted)
+		if player.TimeBank < 0 {
+			player.TimeBank = 0
+		}
+		if game.clockTurn != game.turn && player.TimeBank > 0 {
+			player.TimeBank += time.Duration(game.Options.TimeIncrement) * time.Second
+		}
+	}
+	game.clockPlayer = ""
+}
+
+// timeLeft is what remains of a player's time bank, counting the clock
+// running on their turn
+func timeLeft(game *Game, player Player) time.Duration {
+	left := player.TimeBank
+	if player.ID == game.clockPlayer {
+		left -= time.Since(game.clockStarted)
+	}
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// resetTimeBanks gives every player a full time bank
+func resetTimeBanks(game *Game) {
+	for i := range game.Players {
+		game.Players[i].TimeBank = time.Duration(game.Options.TimeBank) * time.Second
+	}
+}
+
+// expireClock forfeits a player whose time bank has run out. Clocks that
+// were stopped before firing are ignored.
+func (gm *GameManager) expireClock(gameID string, started time.Time) {
+	gm.mutex.Lock()
+
+	game, exists := gm.games[gameID]
+	if !exists || game.clockPlayer == "" || !game.clockStarted.Equal(started) {
+		gm.mutex.Unlock()
+		return
+	}
+
+	playerIndex := findPlayer(game, game.clockPlayer)
+	game.clockTimer = nil
+	game.clockPlayer = ""
+	if playerIndex == -1 {
+		gm.mutex.Unlock()
+		return
+	}
+
+	player := &game.Players[playerIndex]
+	player.TimeBank = 0
+	game.emit(MsgTimeForfeit, TimeForfeitData{PlayerID: player.ID})
+
+	rules := rulesFor(game)
+	if forfeiter, ok := rules.(Forfeiter); ok {
+		forfeiter.Forfeit(game, player)
+		finishIfOver(game, rules)
+	} else {
+		forfeitGame(game, rules, player)
+	}
+	if err := gm.touch(game); err != nil {
+		gm.recordFailure(game, err)
+	}
+
+	finished := game.Phase == PhaseFinished
+	handler := gm.onUpdate
+	gm.mutex.Unlock()
+
+	if handler != nil {
+		handler(gameID, finished)
+	}
+}
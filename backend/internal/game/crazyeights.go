@@ -0,0 +1,181 @@
+package game
+
+// CrazyEightsState is the table state of a Crazy Eights game
+type CrazyEightsState struct {
+	// CurrentSuit is the suit to follow, which differs from the top
+	// card's suit after an eight has been played
+	CurrentSuit Suit `json:"currentSuit"`
+}
+
+// CrazyEights is played on the discard pile in PlayedCards: a card must
+// match the suit or rank of the top card, eights are wild and let the
+// player declare the next suit, and a player who cannot play draws from
+// the deck until they can.
+type CrazyEights struct{}
+
+func (CrazyEights) Name() string { return "crazy_eights" }
+
+func (CrazyEights) MinPlayers() int { return 2 }
+
+func (CrazyEights) MaxPlayers() int { return 7 }
+
+// Deal gives 7 cards each in a two player game and 5 otherwise, then turns
+// up the starter card. A starter eight goes back into the deck.
+func (CrazyEights) Deal(game *Game) error {
+	if game.CrazyEights != nil && game.Phase == PhasePlaying && !(CrazyEights{}).IsOver(game) {
+		return ErrRoundInProgress
+	}
+
+	newDeck(game, createDeck())
+
+	cardsPerPlayer := 5
+	if len(game.Players) == 2 {
+		cardsPerPlayer = 7
+	}
+
+	if err := dealHands(game, cardsPerPlayer); err != nil {
+		return err
+	}
+
+	// Turn up the first card that is not an eight
+	starterIndex := -1
+	for i, card := range game.Deck {
+		if card.Rank != "8" {
+			starterIndex = i
+			break
+		}
+	}
+
+	if starterIndex == -1 {
+		return errorOf(ErrDeckEmpty, "no starter card left in deck")
+	}
+
+	starter := game.Deck[starterIndex]
+	game.Deck = append(game.Deck[:starterIndex], game.Deck[starterIndex+1:]...)
+	game.PlayedCards = append(game.PlayedCards, starter)
+	game.CrazyEights = &CrazyEightsState{CurrentSuit: starter.Suit}
+	game.Phase = PhasePlaying
+
+	return nil
+}
+
+// ValidatePlay checks the card against the top of the discard pile
+func (CrazyEights) ValidatePlay(game *Game, player *Player, play Play) error {
+	if game.CrazyEights == nil || len(game.PlayedCards) == 0 {
+		return ErrNotDealt
+	}
+
+	if play.Card.Rank == "8" {
+		if !FrenchDeck.HasSuit(play.DeclaredSuit) {
+			return errorOf(ErrIllegalCard, "a suit must be declared when playing an eight")
+		}
+		return nil
+	}
+
+	if !crazyEightsPlayable(game, play.Card) {
+		return errorOf(ErrIllegalCard, "card must match the suit or rank of the top card")
+	}
+	return nil
+}
+
+// ApplyPlay discards the card and sets the suit to follow
+func (CrazyEights) ApplyPlay(game *Game, player *Player, play Play) {
+	game.PlayedCards = append(game.PlayedCards, play.Card)
+	game.CrazyEights.CurrentSuit = play.Card.Suit
+
+	if play.Card.Rank == "8" {
+		game.CrazyEights.CurrentSuit = play.DeclaredSuit
+		game.emit(MsgSuitDeclared, SuitDeclaredData{
+			PlayerID: player.ID,
+			Suit:     play.DeclaredSuit,
+		})
+	}
+}
+
+// AdvanceTurn moves to the next player in seat order
+func (CrazyEights) AdvanceTurn(game *Game) {
+	nextPlayer(game)
+}
+
+// IsOver reports whether a player has gone out
+func (CrazyEights) IsOver(game *Game) bool {
+	if game.CrazyEights == nil {
+		return false
+	}
+
+	for _, player := range game.Players {
+		if len(player.Hand) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Score adds the round's points to the players' scores
+func (CrazyEights) Score(game *Game) {
+	points := CrazyEights{}.ScoreRound(game)
+	for i := range game.Players {
+		game.Players[i].Score += points[game.Players[i].ID]
+	}
+}
+
+// ScoreRound gives the player who went out the value of the cards left in
+// the other players' hands
+func (CrazyEights) ScoreRound(game *Game) map[string]int {
+	return goingOutPoints(game, crazyEightsCardPoints)
+}
+
+// Draw takes a card from the deck. Drawing is only allowed while the
+// player has nothing to play. An empty deck is refilled from the discard
+// pile, and when there is nothing left to draw the turn passes.
+func (CrazyEights) Draw(game *Game, player *Player) error {
+	if game.CrazyEights == nil {
+		return ErrNotDealt
+	}
+
+	for _, card := range player.Hand {
+		if card.Rank == "8" || crazyEightsPlayable(game, card) {
+			return errorOf(ErrIllegalMove, "you have a playable card")
+		}
+	}
+
+	if len(game.Deck) == 0 && !refillDeck(game) {
+		nextPlayer(game)
+		return nil
+	}
+
+	drawn := game.Deck[0]
+	game.Deck = game.Deck[1:]
+	player.Hand = append(player.Hand, drawn)
+	game.emit(MsgCardDrawn, CardDrawnData{PlayerID: player.ID})
+
+	// Nothing to play and nothing left to draw
+	if len(game.Deck) == 0 && len(game.PlayedCards) <= 1 && drawn.Rank != "8" && !crazyEightsPlayable(game, drawn) {
+		nextPlayer(game)
+	}
+
+	return nil
+}
+
+// ViewState exposes the suit to follow
+func (CrazyEights) ViewState(game *Game, viewerID string) interface{} {
+	return game.CrazyEights
+}
+
+// crazyEightsPlayable reports whether a non-wild card matches the top card
+func crazyEightsPlayable(game *Game, card Card) bool {
+	top := game.PlayedCards[len(game.PlayedCards)-1]
+	return card.Suit == game.CrazyEights.CurrentSuit || card.Rank == top.Rank
+}
+
+// crazyEightsCardPoints is the penalty value of a card left in hand
+func crazyEightsCardPoints(card Card) int {
+	switch card.Rank {
+	case "8":
+		return 50
+	case "J", "Q", "K":
+		return 10
+	default:
+		return card.Value
+	}
+}
@@ -0,0 +1,164 @@
+package game
+
+import "fmt"
+
+// Joker is the suit and rank of jokers, which belong to no suit
+const Joker Suit = "joker"
+
+// jokersPerDeck is the number of jokers added to each deck of a shoe
+const jokersPerDeck = 2
+
+// RankSpec is a rank of a deck and its face value
+type RankSpec struct {
+	Rank  string `json:"rank"`
+	Value int    `json:"value"`
+}
+
+// DeckSpec describes a deck of cards by its suits and ranks, so rulesets
+// can play with decks other than the French 52-card deck
+type DeckSpec struct {
+	Name  string     `json:"name"`
+	Suits []Suit     `json:"suits"`
+	Ranks []RankSpec `json:"ranks"`
+}
+
+// FrenchDeck is the standard 52-card deck
+var FrenchDeck = DeckSpec{
+	Name:  "french",
+	Suits: []Suit{Hearts, Diamonds, Clubs, Spades},
+	Ranks: []RankSpec{
+		{"A", 1}, {"2", 2}, {"3", 3}, {"4", 4}, {"5", 5}, {"6", 6}, {"7", 7},
+		{"8", 8}, {"9", 9}, {"10", 10}, {"J", 11}, {"Q", 12}, {"K", 13},
+	},
+}
+
+// SpanishDeck is the 40-card baraja española: ranks 1 to 7 and the sota
+// (10), caballo (11) and rey (12)
+var SpanishDeck = DeckSpec{
+	Name:  "spanish",
+	Suits: []Suit{Oros, Copas, Espadas, Bastos},
+	Ranks: []RankSpec{
+		{"1", 1}, {"2", 2}, {"3", 3}, {"4", 4}, {"5", 5}, {"6", 6}, {"7", 7},
+		{"10", 10}, {"11", 11}, {"12", 12},
+	},
+}
+
+// Size returns the number of cards in the deck
+func (d DeckSpec) Size() int {
+	return len(d.Suits) * len(d.Ranks)
+}
+
+// HasSuit reports whether the suit belongs to the deck
+func (d DeckSpec) HasSuit(suit Suit) bool {
+	for _, s := range d.Suits {
+		if s == suit {
+			return true
+		}
+	}
+	return false
+}
+
+// Strip returns the spec without the given ranks, such as the 32-card
+// piquet deck made by stripping the twos to sixes from a French deck
+func (d DeckSpec) Strip(ranks ...string) DeckSpec {
+	stripped := DeckSpec{Name: d.Name, Suits: d.Suits, Ranks: make([]RankSpec, 0, len(d.Ranks))}
+	for _, rank := range d.Ranks {
+		keep := true
+		for _, r := range ranks {
+			if rank.Rank == r {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			stripped.Ranks = append(stripped.Ranks, rank)
+		}
+	}
+	return stripped
+}
+
+// PiquetDeck is the 32-card French deck from the seven up
+var PiquetDeck = func() DeckSpec {
+	spec := FrenchDeck.Strip("2", "3", "4", "5", "6")
+	spec.Name = "piquet"
+	return spec
+}()
+
+// DeckBuilder composes the cards of a game from one or more decks of a
+// spec, with jokers added to each deck. Every card gets an ID made of its
+// suit, rank and copy, so the same physical card keeps its ID for the
+// whole game however often the cards are rebuilt and shuffled.
+type DeckBuilder struct {
+	Spec DeckSpec
+	// Decks is the number of decks shuffled together, 1 if zero
+	Decks  int
+	Jokers int
+}
+
+// Size returns the number of cards Build creates
+func (b DeckBuilder) Size() int {
+	return max(b.Decks, 1) * (b.Spec.Size() + b.Jokers)
+}
+
+// Build creates the unshuffled cards
+func (b DeckBuilder) Build() []Card {
+	decks := b.Decks
+	if decks == 0 {
+		decks = 1
+	}
+
+	cards := make([]Card, 0, b.Size())
+	for d := 1; d <= decks; d++ {
+		for _, suit := range b.Spec.Suits {
+			for _, rank := range b.Spec.Ranks {
+				cards = append(cards, Card{
+					ID:    fmt.Sprintf("%s-%s-%d", suit, rank.Rank, d),
+					Suit:  suit,
+					Rank:  rank.Rank,
+					Value: rank.Value,
+				})
+			}
+		}
+	}
+
+	for i := 1; i <= decks*b.Jokers; i++ {
+		cards = append(cards, Card{
+			ID:   fmt.Sprintf("%s-%d", Joker, i),
+			Suit: Joker,
+			Rank: string(Joker),
+		})
+	}
+	return cards
+}
+
+// NewDeck creates one unshuffled deck following the spec
+func NewDeck(spec DeckSpec) []Card {
+	return DeckBuilder{Spec: spec}.Build()
+}
+
+// createDeck creates a standard 52-card deck
+func createDeck() []Card {
+	return NewDeck(FrenchDeck)
+}
+
+// IsJoker reports whether a card is a joker
+func IsJoker(card Card) bool {
+	return card.Suit == Joker
+}
+
+// WildCards returns a wild card test for rulesets that play with wild
+// cards. Jokers are always wild and the ranks given are wild as well, such
+// as the twos in Canasta.
+func WildCards(ranks ...string) func(Card) bool {
+	return func(card Card) bool {
+		if IsJoker(card) {
+			return true
+		}
+		for _, rank := range ranks {
+			if card.Rank == rank {
+				return true
+			}
+		}
+		return false
+	}
+}
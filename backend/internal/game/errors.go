@@ -0,0 +1,105 @@
+package game
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorCode identifies an error sent to clients, so they can react to it
+// and show their own message for it
+type ErrorCode string
+
+const (
+	CodeInvalidMessage     ErrorCode = "INVALID_MESSAGE"
+	CodeUnknownMessage     ErrorCode = "UNKNOWN_MESSAGE_TYPE"
+	CodeInvalidPayload     ErrorCode = "INVALID_PAYLOAD"
+	CodeNotInGame          ErrorCode = "NOT_IN_GAME"
+	CodeGameNotFound       ErrorCode = "GAME_NOT_FOUND"
+	CodePlayerNotFound     ErrorCode = "PLAYER_NOT_FOUND"
+	CodeUnknownRuleSet     ErrorCode = "UNKNOWN_RULESET"
+	CodeInvalidOptions     ErrorCode = "INVALID_OPTIONS"
+	CodeGameFull           ErrorCode = "GAME_FULL"
+	CodeNameTaken          ErrorCode = "NAME_TAKEN"
+	CodeInvalidResumeToken ErrorCode = "INVALID_RESUME_TOKEN"
+	CodeNotEnoughPlayers   ErrorCode = "NOT_ENOUGH_PLAYERS"
+	CodeWrongPhase         ErrorCode = "WRONG_PHASE"
+	CodeGamePaused         ErrorCode = "GAME_PAUSED"
+	CodePlayerDisconnected ErrorCode = "PLAYER_DISCONNECTED"
+	CodeNotYourTurn        ErrorCode = "NOT_YOUR_TURN"
+	CodeStaleVersion       ErrorCode = "STALE_VERSION"
+	CodeCardNotInHand      ErrorCode = "CARD_NOT_IN_HAND"
+	CodeActionNotAllowed   ErrorCode = "ACTION_NOT_ALLOWED"
+	CodeRoundInProgress    ErrorCode = "ROUND_IN_PROGRESS"
+	CodeDeckEmpty          ErrorCode = "DECK_EMPTY"
+	CodeIllegalCard        ErrorCode = "ILLEGAL_CARD"
+	CodeNotEnoughChips     ErrorCode = "NOT_ENOUGH_CHIPS"
+	CodeInvalidBet         ErrorCode = "INVALID_BET"
+	CodeInvalidMeld        ErrorCode = "INVALID_MELD"
+	// CodeInternalError is a failure of the server, not of the move
+	CodeInternalError ErrorCode = "INTERNAL_ERROR"
+	// CodeInvalidMove is any other move the ruleset does not accept
+	CodeInvalidMove ErrorCode = "INVALID_MOVE"
+)
+
+// Key is the key clients look the error's message up by, such as
+// "errors.not_your_turn"
+func (c ErrorCode) Key() string {
+	return "errors." + strings.ToLower(string(c))
+}
+
+// Errors returned by the game manager. Errors of the same kind with their
+// own message, like the options that were invalid, match these with
+// errors.Is.
+var (
+	ErrGameNotFound       = errors.New("game not found")
+	ErrPlayerNotFound     = errors.New("player not found")
+	ErrUnknownRuleSet     = errors.New("unknown ruleset")
+	ErrInvalidOptions     = errors.New("invalid game options")
+	ErrGameFull           = errors.New("game is full")
+	ErrNameTaken          = errors.New("player name already exists")
+	ErrInvalidResumeToken = errors.New("invalid resume token")
+	ErrNotEnoughPlayers   = errors.New("not enough players")
+	ErrNotPlaying         = errors.New("game is not in playing phase")
+	ErrNotPassing         = errors.New("game is not in passing phase")
+	ErrGamePaused         = errors.New("game is paused")
+	ErrGameNotPaused      = errors.New("game is not paused")
+	ErrPlayerDisconnected = errors.New("waiting for a player to reconnect")
+	ErrNotYourTurn        = errors.New("not your turn")
+	ErrStaleVersion       = errors.New("game has changed")
+	ErrDuplicateRequest   = errors.New("request has been handled already")
+	ErrCardNotInHand      = errors.New("card not found in player's hand")
+	ErrActionNotAllowed   = errors.New("action not allowed in this game")
+	ErrCardsMismatch      = errors.New("the cards of the game do not add up")
+)
+
+// Kinds of the moves rulesets reject, which carry their own message
+var (
+	ErrRoundInProgress = errors.New("a round is already in progress")
+	ErrNotDealt        = errors.New("cards have not been dealt")
+	ErrDeckEmpty       = errors.New("not enough cards in deck")
+	ErrIllegalCard     = errors.New("card cannot be played")
+	ErrNotEnoughChips  = errors.New("not enough chips")
+	ErrInvalidBet      = errors.New("invalid bet")
+	ErrInvalidMeld     = errors.New("invalid meld")
+	ErrIllegalMove     = errors.New("move not allowed by the rules")
+)
+
+// kindError is an error with its own message that is of the kind of one
+// of the errors above
+type kindError struct {
+	kind    error
+	message string
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// errorOf returns an error with the given message of the kind given
+func errorOf(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
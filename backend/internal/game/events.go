@@ -0,0 +1,36 @@
+package game
+
+// Event is a notification raised by a ruleset while applying a move. The hub
+// forwards pending events to the clients of the game after each action.
+type Event struct {
+	Type MessageType
+	Data interface{}
+	// PlayerID restricts the event to a single player when set
+	PlayerID string
+}
+
+// emit queues an event for every player in the game
+func (g *Game) emit(msgType MessageType, data interface{}) {
+	g.events = append(g.events, Event{Type: msgType, Data: data})
+}
+
+// emitTo queues an event that only the given player receives
+func (g *Game) emitTo(playerID string, msgType MessageType, data interface{}) {
+	g.events = append(g.events, Event{Type: msgType, Data: data, PlayerID: playerID})
+}
+
+// TakeEvents returns and clears the events queued for a game
+func (gm *GameManager) TakeEvents(gameID string) []Event {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		return nil
+	}
+
+	events := game.events
+	game.events = nil
+	game.savedEvents = 0
+	return events
+}
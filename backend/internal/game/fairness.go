@@ -0,0 +1,143 @@
+package game
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"strings"
+)
+
+// FairShuffle is the commit-reveal record of a provably fair game. The
+// hash of the server seed is published before any player joins, the deck
+// order of every shuffle is committed before cards are dealt, and the
+// server seed is revealed once the game has ended so anyone can check the
+// deals with VerifyShuffle.
+type FairShuffle struct {
+	ServerSeed     string              `json:"serverSeed"`
+	ServerSeedHash string              `json:"serverSeedHash"`
+	ClientSeeds    []ClientSeed        `json:"clientSeeds"`
+	Commitments    []ShuffleCommitment `json:"commitments"`
+}
+
+// ClientSeed is a seed contributed by a player when joining
+type ClientSeed struct {
+	PlayerID string `json:"playerId"`
+	Seed     string `json:"seed"`
+}
+
+// ShuffleCommitment is the hash of the server seed and the deck order
+// produced by one shuffle of the game
+type ShuffleCommitment struct {
+	Shuffle int    `json:"shuffle"`
+	Hash    string `json:"hash"`
+}
+
+// FairnessView is the fairness record sent to clients. The server seed is
+// only included once the game has ended.
+type FairnessView struct {
+	ServerSeed     string              `json:"serverSeed,omitempty"`
+	ServerSeedHash string              `json:"serverSeedHash"`
+	ClientSeeds    []ClientSeed        `json:"clientSeeds"`
+	Commitments    []ShuffleCommitment `json:"commitments"`
+}
+
+// newFairShuffle draws a fresh server seed and publishes its hash
+func newFairShuffle(clientSeeds []ClientSeed) (*FairShuffle, error) {
+	var buf [32]byte
+	if _, err := cryptorand.Read(buf[:]); err != nil {
+		return nil, errors.New("could not generate a server seed")
+	}
+
+	serverSeed := hex.EncodeToString(buf[:])
+	return &FairShuffle{
+		ServerSeed:     serverSeed,
+		ServerSeedHash: hashHex(serverSeed),
+		ClientSeeds:    append(make([]ClientSeed, 0, len(clientSeeds)), clientSeeds...),
+		Commitments:    make([]ShuffleCommitment, 0),
+	}, nil
+}
+
+// AddClientSeed records a player's seed. Seeds are only taken until the
+// first shuffle, after which the game seed is fixed.
+func (f *FairShuffle) AddClientSeed(playerID, seed string) {
+	if seed == "" || len(f.Commitments) > 0 {
+		return
+	}
+	f.ClientSeeds = append(f.ClientSeeds, ClientSeed{PlayerID: playerID, Seed: seed})
+}
+
+// GameSeed combines the server seed with the client seeds, in the order
+// the players joined, into the seed every shuffle derives from
+func (f *FairShuffle) GameSeed() int64 {
+	parts := []string{f.ServerSeed}
+	for _, clientSeed := range f.ClientSeeds {
+		parts = append(parts, clientSeed.Seed)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, ":")))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// View returns the record as clients may see it
+func (f *FairShuffle) View(revealed bool) *FairnessView {
+	view := &FairnessView{
+		ServerSeedHash: f.ServerSeedHash,
+		ClientSeeds:    append(make([]ClientSeed, 0, len(f.ClientSeeds)), f.ClientSeeds...),
+		Commitments:    append(make([]ShuffleCommitment, 0, len(f.Commitments)), f.Commitments...),
+	}
+
+	if revealed {
+		view.ServerSeed = f.ServerSeed
+	}
+	return view
+}
+
+// VerifyShuffle checks one shuffle of a provably fair game once its server
+// seed has been revealed. The deck is the cards in the order they were in
+// before the shuffle; shuffling them again from the seeds must give the
+// deck order the server committed to.
+func VerifyShuffle(fairness FairnessView, shuffle int, deck []Card) error {
+	if hashHex(fairness.ServerSeed) != fairness.ServerSeedHash {
+		return errors.New("server seed does not match its hash")
+	}
+
+	commitment := -1
+	for i, c := range fairness.Commitments {
+		if c.Shuffle == shuffle {
+			commitment = i
+			break
+		}
+	}
+
+	if commitment == -1 {
+		return errors.New("no commitment for this shuffle")
+	}
+
+	record := FairShuffle{ServerSeed: fairness.ServerSeed, ClientSeeds: fairness.ClientSeeds}
+	shuffled := append(make([]Card, 0, len(deck)), deck...)
+	shuffleCards(rand.New(rand.NewSource(shuffleSeed(record.GameSeed(), shuffle))), shuffled)
+
+	if deckHash(fairness.ServerSeed, shuffled) != fairness.Commitments[commitment].Hash {
+		return errors.New("deck order does not match the commitment")
+	}
+	return nil
+}
+
+// deckHash commits to a deck order. Card IDs are left out as they are not
+// derived from the seeds.
+func deckHash(serverSeed string, deck []Card) string {
+	cards := make([]string, len(deck))
+	for i, card := range deck {
+		cards[i] = card.Rank + "-" + string(card.Suit)
+	}
+	return hashHex(serverSeed + ":" + strings.Join(cards, ","))
+}
+
+// hashHex is the hex encoded SHA-256 of a string
+func hashHex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package game
+
+import "testing"
+
+func TestVerifyShuffle(t *testing.T) {
+	fairness, err := newFairShuffle([]ClientSeed{{PlayerID: "alice", Seed: "lucky"}})
+	if err != nil {
+		t.Fatalf("creating the record: %v", err)
+	}
+
+	// Two shuffles of a fair game, keeping each deck as it was before
+	game := &Game{Fairness: fairness}
+	before := make([][]Card, 2)
+	for shuffle := range before {
+		deck := createDeck()
+		before[shuffle] = append([]Card{}, deck...)
+		shuffleDeck(game, deck)
+	}
+	revealed := *fairness.View(true)
+
+	swapped := append([]Card{}, before[0]...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+
+	otherClient := revealed
+	otherClient.ClientSeeds = []ClientSeed{{PlayerID: "alice", Seed: "unlucky"}}
+
+	otherServer := revealed
+	otherServer.ServerSeed = "not-the-server-seed"
+
+	tests := []struct {
+		name     string
+		fairness FairnessView
+		shuffle  int
+		deck     []Card
+		wantErr  bool
+	}{
+		{"first shuffle", revealed, 0, before[0], false},
+		{"second shuffle", revealed, 1, before[1], false},
+		{"deck in another order", revealed, 0, swapped, true},
+		{"deck of another shuffle", revealed, 1, swapped, true},
+		{"shuffle never made", revealed, 2, before[0], true},
+		{"another client seed", otherClient, 0, before[0], true},
+		{"server seed not matching its hash", otherServer, 0, before[0], true},
+		{"server seed not revealed", *fairness.View(false), 0, before[0], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyShuffle(tt.fairness, tt.shuffle, tt.deck)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
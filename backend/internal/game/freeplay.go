@@ -0,0 +1,99 @@
+package game
+
+// FreePlay is the default ruleset: any card may be played, the turn passes
+// to the next player and the first player to empty their hand wins. Players
+// may also draw from the deck and end their turn with a discard.
+type FreePlay struct{}
+
+func (FreePlay) Name() string { return DefaultRuleSet }
+
+func (FreePlay) MinPlayers() int { return 2 }
+
+func (FreePlay) MaxPlayers() int { return 4 }
+
+// Deal gives each player the table's hand size, 5 cards by default
+func (FreePlay) Deal(game *Game) error {
+	if game.Phase == PhasePlaying && !(FreePlay{}).IsOver(game) {
+		return ErrRoundInProgress
+	}
+
+	cardsPerPlayer := game.Options.HandSize
+	if cardsPerPlayer == 0 {
+		cardsPerPlayer = 5
+	}
+	if err := dealHands(game, cardsPerPlayer); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidatePlay accepts any card from the player's hand
+func (FreePlay) ValidatePlay(game *Game, player *Player, play Play) error {
+	return nil
+}
+
+// ApplyPlay puts the card on the table in the shared zone. PlayedCards is
+// the discard pile.
+func (FreePlay) ApplyPlay(game *Game, player *Player, play Play) {
+	game.SharedZone = append(game.SharedZone, play.Card)
+}
+
+// AdvanceTurn moves to the next player in seat order
+func (FreePlay) AdvanceTurn(game *Game) {
+	nextPlayer(game)
+}
+
+// IsOver reports whether a player has emptied their hand
+func (FreePlay) IsOver(game *Game) bool {
+	for _, player := range game.Players {
+		if len(player.Hand) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Score adds the round's points to the players' scores
+func (FreePlay) Score(game *Game) {
+	points := FreePlay{}.ScoreRound(game)
+	for i := range game.Players {
+		game.Players[i].Score += points[game.Players[i].ID]
+	}
+}
+
+// ScoreRound gives the player who emptied their hand the value of the
+// cards left in the other players' hands
+func (FreePlay) ScoreRound(game *Game) map[string]int {
+	return goingOutPoints(game, func(card Card) int { return card.Value })
+}
+
+// Draw takes the top card of the deck, reshuffling the discard pile when
+// the deck has run out. Drawing does not end the turn.
+func (FreePlay) Draw(game *Game, player *Player) error {
+	if len(game.Deck) == 0 && !refillDeck(game) {
+		return errorOf(ErrDeckEmpty, "no cards left to draw")
+	}
+
+	player.Hand = append(player.Hand, game.Deck[0])
+	game.Deck = game.Deck[1:]
+	game.emit(MsgCardDrawn, CardDrawnData{PlayerID: player.ID})
+	return nil
+}
+
+// Discard puts the card on the discard pile and ends the turn
+func (FreePlay) Discard(game *Game, player *Player, card Card) error {
+	player.Hand = removeCard(player.Hand, card.ID)
+	game.PlayedCards = append(game.PlayedCards, card)
+	game.emit(MsgCardDiscarded, CardDiscardedData{PlayerID: player.ID, Card: card})
+	nextPlayer(game)
+	return nil
+}
+
+// Drop moves the cards to the shared zone without ending the turn
+func (FreePlay) Drop(game *Game, player *Player, cards []Card) error {
+	for _, card := range cards {
+		player.Hand = removeCard(player.Hand, card.ID)
+	}
+	game.SharedZone = append(game.SharedZone, cards...)
+	return nil
+}
@@ -0,0 +1,408 @@
+package game
+
+const (
+	ginPlayers      = 2
+	ginHandSize     = 10
+	ginKnockLimit   = 10
+	ginBonus        = 25
+	ginUndercut     = 25
+	ginGameOver     = 100
+	ginStockReserve = 2
+)
+
+// ginMelds are the melds of Gin Rummy: three or more cards with the ace
+// always low and no wild cards
+var ginMelds = MeldRules{MinSize: 3}
+
+// GinRummyState is the table state of a Gin Rummy game. The discard pile
+// is kept in the game's PlayedCards with the top card last, and melds are
+// laid down in the game's Melds once a player knocks.
+type GinRummyState struct {
+	Round  int    `json:"round"`
+	Dealer string `json:"dealer"`
+	// Drawn is set once the current player has drawn this turn, and
+	// TakenDiscard is the discard they took, which they cannot throw back
+	Drawn        bool   `json:"drawn"`
+	TakenDiscard string `json:"takenDiscard,omitempty"`
+	// Knocker ends the round. Their deadwood is shown to everyone while
+	// the other player lays down and lays off.
+	Knocker         string `json:"knocker,omitempty"`
+	KnockerDeadwood []Card `json:"knockerDeadwood,omitempty"`
+	Gin             bool   `json:"gin"`
+}
+
+type KnockData struct {
+	CardID string `json:"cardId"`
+}
+
+type KnockedData struct {
+	PlayerID string `json:"playerId"`
+	Deadwood int    `json:"deadwood"`
+	Gin      bool   `json:"gin"`
+}
+
+type MeldLaidData struct {
+	PlayerID string `json:"playerId"`
+	Meld     Meld   `json:"meld"`
+}
+
+type CardsLaidOffData struct {
+	PlayerID string `json:"playerId"`
+	MeldID   string `json:"meldId"`
+	Cards    []Card `json:"cards"`
+}
+
+// GinRummy is two player Gin Rummy. Each turn a player draws from the
+// stock or the discard pile and discards. A player whose unmelded cards
+// are worth 10 or less may knock; the other player then lays down their
+// melds, lays off cards on the knocker's melds and ends their turn, and
+// the difference in deadwood is scored. Going gin earns a bonus, as does
+// undercutting the knocker, and the game is played to 100.
+type GinRummy struct{}
+
+func (GinRummy) Name() string { return "gin_rummy" }
+
+func (GinRummy) MinPlayers() int { return ginPlayers }
+
+func (GinRummy) MaxPlayers() int { return ginPlayers }
+
+// Deal starts a new game
+func (GinRummy) Deal(game *Game) error {
+	if game.GinRummy != nil && game.Phase != PhaseFinished {
+		return errorOf(ErrRoundInProgress, "a game is already in progress")
+	}
+
+	game.GinRummy = &GinRummyState{}
+	for i := range game.Players {
+		game.Players[i].Score = 0
+	}
+
+	return ginDealRound(game)
+}
+
+// ValidatePlay only allows a discard once the player has drawn, and not the
+// card just taken from the discard pile
+func (GinRummy) ValidatePlay(game *Game, player *Player, play Play) error {
+	state := game.GinRummy
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if state.Knocker != "" {
+		return errorOf(ErrIllegalMove, "the round has been knocked")
+	}
+
+	if !state.Drawn {
+		return errorOf(ErrIllegalMove, "you must draw before discarding")
+	}
+
+	if play.Card.ID == state.TakenDiscard {
+		return errorOf(ErrIllegalCard, "you cannot discard the card you just took")
+	}
+	return nil
+}
+
+// ApplyPlay puts the card on the discard pile
+func (GinRummy) ApplyPlay(game *Game, player *Player, play Play) {
+	game.PlayedCards = append(game.PlayedCards, play.Card)
+	game.GinRummy.Drawn = false
+	game.GinRummy.TakenDiscard = ""
+}
+
+// AdvanceTurn passes the turn. When the stock is down to its last two
+// cards the round is a draw and is dealt again.
+func (GinRummy) AdvanceTurn(game *Game) {
+	if len(game.Deck) <= ginStockReserve {
+		game.emit(MsgHandScored, HandScoredData{Points: make(map[string]int)})
+		ginDealRound(game)
+		return
+	}
+	nextPlayer(game)
+}
+
+// Discard ends the turn with a discard once the player has drawn, the same
+// move as playing the card
+func (GinRummy) Discard(game *Game, player *Player, card Card) error {
+	play := Play{Card: card}
+	if err := (GinRummy{}).ValidatePlay(game, player, play); err != nil {
+		return err
+	}
+
+	game.emit(MsgCardDiscarded, CardDiscardedData{PlayerID: player.ID, Card: card})
+	applyPlay(game, GinRummy{}, player, play)
+	return nil
+}
+
+// IsOver reports whether a player has reached 100 points
+func (GinRummy) IsOver(game *Game) bool {
+	if game.GinRummy == nil {
+		return false
+	}
+
+	for _, player := range game.Players {
+		if player.Score >= ginGameOver {
+			return true
+		}
+	}
+	return false
+}
+
+// Score does nothing as rounds are scored as they end
+func (GinRummy) Score(game *Game) {}
+
+// Draw takes the top card of the stock
+func (GinRummy) Draw(game *Game, player *Player) error {
+	if err := ginCanDraw(game); err != nil {
+		return err
+	}
+
+	player.Hand = append(player.Hand, game.Deck[0])
+	game.Deck = game.Deck[1:]
+	game.GinRummy.Drawn = true
+	game.emit(MsgCardDrawn, CardDrawnData{PlayerID: player.ID})
+	return nil
+}
+
+// DrawDiscard takes the top card of the discard pile
+func (GinRummy) DrawDiscard(game *Game, player *Player) error {
+	if err := ginCanDraw(game); err != nil {
+		return err
+	}
+
+	if len(game.PlayedCards) == 0 {
+		return errorOf(ErrDeckEmpty, "the discard pile is empty")
+	}
+
+	top := game.PlayedCards[len(game.PlayedCards)-1]
+	game.PlayedCards = game.PlayedCards[:len(game.PlayedCards)-1]
+	player.Hand = append(player.Hand, top)
+	game.GinRummy.Drawn = true
+	game.GinRummy.TakenDiscard = top.ID
+	game.emit(MsgCardDrawn, CardDrawnData{PlayerID: player.ID, Card: &top})
+	return nil
+}
+
+// Knock discards a card and ends the round. The knocker's cards are laid
+// down in their best melds and what is left must be worth 10 or less.
+func (GinRummy) Knock(game *Game, player *Player, card Card) error {
+	state := game.GinRummy
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if state.Knocker != "" {
+		return errorOf(ErrIllegalMove, "the round has already been knocked")
+	}
+
+	if !state.Drawn {
+		return errorOf(ErrIllegalMove, "you must draw before knocking")
+	}
+
+	if card.ID == state.TakenDiscard {
+		return errorOf(ErrIllegalCard, "you cannot discard the card you just took")
+	}
+
+	remaining := removeCard(append([]Card{}, player.Hand...), card.ID)
+	melds, deadwood := ginMelds.BestMelds(remaining, ginCardPoints)
+	points := ginDeadwood(deadwood)
+	if points > ginKnockLimit {
+		return errorOf(ErrIllegalMove, "you need 10 or less deadwood to knock")
+	}
+
+	game.PlayedCards = append(game.PlayedCards, card)
+	for _, cards := range melds {
+		meld, _ := ginMelds.NewMeld(player.ID, cards)
+		game.Melds = append(game.Melds, meld)
+		game.emit(MsgMeldLaid, MeldLaidData{PlayerID: player.ID, Meld: meld})
+	}
+
+	player.Hand = deadwood
+	state.Drawn = false
+	state.TakenDiscard = ""
+	state.Knocker = player.ID
+	state.KnockerDeadwood = deadwood
+	state.Gin = points == 0
+	game.emit(MsgKnocked, KnockedData{PlayerID: player.ID, Deadwood: points, Gin: state.Gin})
+
+	nextPlayer(game)
+	return nil
+}
+
+// LayDown lays a meld from the hand of the player answering a knock
+func (GinRummy) LayDown(game *Game, player *Player, cards []Card) error {
+	if err := ginCanLay(game, player); err != nil {
+		return err
+	}
+
+	meld, err := ginMelds.NewMeld(player.ID, cards)
+	if err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		player.Hand = removeCard(player.Hand, card.ID)
+	}
+	game.Melds = append(game.Melds, meld)
+	game.emit(MsgMeldLaid, MeldLaidData{PlayerID: player.ID, Meld: meld})
+	return nil
+}
+
+// LayOff adds cards to one of the knocker's melds, which is not allowed
+// when the knocker went gin
+func (GinRummy) LayOff(game *Game, player *Player, meldID string, cards []Card) error {
+	state := game.GinRummy
+	if err := ginCanLay(game, player); err != nil {
+		return err
+	}
+
+	if state.Gin {
+		return errorOf(ErrInvalidMeld, "you cannot lay off against gin")
+	}
+
+	meldIndex := findMeld(game, meldID)
+	if meldIndex == -1 || game.Melds[meldIndex].OwnerID != state.Knocker {
+		return errorOf(ErrInvalidMeld, "cards can only be laid off on the knocker's melds")
+	}
+
+	meld, err := ginMelds.Extend(game.Melds[meldIndex], cards)
+	if err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		player.Hand = removeCard(player.Hand, card.ID)
+	}
+	game.Melds[meldIndex] = meld
+	game.emit(MsgCardsLaidOff, CardsLaidOffData{PlayerID: player.ID, MeldID: meldID, Cards: cards})
+	return nil
+}
+
+// EndTurn finishes the reply to a knock. Cards left in hand are arranged
+// in their best melds, the round is scored and the next one dealt.
+func (GinRummy) EndTurn(game *Game, player *Player) error {
+	state := game.GinRummy
+	if err := ginCanLay(game, player); err != nil {
+		return err
+	}
+
+	melds, deadwood := ginMelds.BestMelds(player.Hand, ginCardPoints)
+	for _, cards := range melds {
+		meld, _ := ginMelds.NewMeld(player.ID, cards)
+		game.Melds = append(game.Melds, meld)
+		game.emit(MsgMeldLaid, MeldLaidData{PlayerID: player.ID, Meld: meld})
+	}
+	player.Hand = deadwood
+
+	knockerPoints := ginDeadwood(state.KnockerDeadwood)
+	defenderPoints := ginDeadwood(deadwood)
+	knocker := &game.Players[findPlayer(game, state.Knocker)]
+
+	points := make(map[string]int)
+	switch {
+	case state.Gin:
+		points[knocker.ID] = defenderPoints + ginBonus
+	case defenderPoints <= knockerPoints:
+		points[player.ID] = knockerPoints - defenderPoints + ginUndercut
+	default:
+		points[knocker.ID] = defenderPoints - knockerPoints
+	}
+
+	for i := range game.Players {
+		game.Players[i].Score += points[game.Players[i].ID]
+	}
+	game.emit(MsgHandScored, HandScoredData{Points: points})
+
+	if !(GinRummy{}).IsOver(game) {
+		ginDealRound(game)
+	}
+	return nil
+}
+
+// ViewState exposes the round, the turn's progress and the knock
+func (GinRummy) ViewState(game *Game, viewerID string) interface{} {
+	if game.GinRummy == nil {
+		return nil
+	}
+	return *game.GinRummy
+}
+
+// ginDealRound deals ten cards each and turns up the first discard. The
+// deal alternates and the player after the dealer goes first.
+func ginDealRound(game *Game) error {
+	state := game.GinRummy
+
+	dealerIndex := 0
+	if state.Round > 0 {
+		dealerIndex = (findPlayer(game, state.Dealer) + 1) % len(game.Players)
+	}
+
+	state.Round++
+	state.Dealer = game.Players[dealerIndex].ID
+	state.Drawn = false
+	state.TakenDiscard = ""
+	state.Knocker = ""
+	state.KnockerDeadwood = nil
+	state.Gin = false
+
+	newDeck(game, createDeck())
+	game.Melds = make([]Meld, 0)
+
+	if err := dealHands(game, ginHandSize); err != nil {
+		return err
+	}
+
+	game.PlayedCards = append(game.PlayedCards, game.Deck[0])
+	game.Deck = game.Deck[1:]
+
+	game.Phase = PhasePlaying
+	setCurrentPlayer(game, game.Players[(dealerIndex+1)%len(game.Players)].ID)
+	return nil
+}
+
+// ginCanDraw checks that the current player may still draw this turn
+func ginCanDraw(game *Game) error {
+	state := game.GinRummy
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if state.Knocker != "" {
+		return errorOf(ErrIllegalMove, "the round has been knocked")
+	}
+
+	if state.Drawn {
+		return errorOf(ErrIllegalMove, "you have already drawn this turn")
+	}
+	return nil
+}
+
+// ginCanLay checks that the player is answering a knock
+func ginCanLay(game *Game, player *Player) error {
+	state := game.GinRummy
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if state.Knocker == "" || state.Knocker == player.ID {
+		return errorOf(ErrInvalidMeld, "melds are laid down after the other player knocks")
+	}
+	return nil
+}
+
+// ginDeadwood adds up the value of unmelded cards
+func ginDeadwood(cards []Card) int {
+	points := 0
+	for _, card := range cards {
+		points += ginCardPoints(card)
+	}
+	return points
+}
+
+// ginCardPoints is the deadwood value of a card: face cards count 10 and
+// the ace 1
+func ginCardPoints(card Card) int {
+	if card.Value > 10 {
+		return 10
+	}
+	return card.Value
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGinDiscard(t *testing.T) {
+	tests := []struct {
+		name string
+		// draw is where the card is drawn from, none when it is empty
+		draw  string
+		taken bool
+		want  error
+	}{
+		{name: "after drawing from the stock", draw: "stock"},
+		{name: "after taking the discard", draw: "discard"},
+		{name: "before drawing", want: ErrIllegalMove},
+		{name: "the discard just taken", draw: "discard", taken: true, want: ErrIllegalCard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm, game, _ := newTestGame(t, GameOptions{RuleSet: "gin_rummy"}, "alice", "bob")
+			if _, err := gm.DealCards(requestFor(game, game.Players[0].ID)); err != nil {
+				t.Fatalf("dealing: %v", err)
+			}
+
+			current := game.CurrentPlayer
+			request := requestFor(game, current)
+			if tt.draw != "" {
+				if _, err := gm.DrawCard(request, tt.draw == "discard"); err != nil {
+					t.Fatalf("drawing: %v", err)
+				}
+			}
+
+			player := &game.Players[findPlayer(game, current)]
+			card := player.Hand[0]
+			if tt.taken {
+				card = player.Hand[len(player.Hand)-1]
+			}
+
+			_, err := gm.DiscardCard(request, card.ID)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+				if game.CurrentPlayer != current {
+					t.Fatal("rejected discard passed the turn")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("discarding: %v", err)
+			}
+			top := game.PlayedCards[len(game.PlayedCards)-1]
+			if top.ID != card.ID || len(player.Hand) != ginHandSize || game.CurrentPlayer == current {
+				t.Fatalf("top discard %s, %d cards in hand, %s to play", top.ID, len(player.Hand), game.CurrentPlayer)
+			}
+		})
+	}
+}
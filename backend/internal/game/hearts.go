@@ -0,0 +1,390 @@
+package game
+
+import "sort"
+
+// PassDirection is where the passed cards go in a round of Hearts
+type PassDirection string
+
+const (
+	PassLeft   PassDirection = "left"
+	PassRight  PassDirection = "right"
+	PassAcross PassDirection = "across"
+	PassHold   PassDirection = "hold"
+)
+
+const (
+	heartsPlayers    = 4
+	heartsHandSize   = 13
+	heartsPassSize   = 3
+	heartsGameOver   = 100
+	heartsMoonPoints = 26
+)
+
+// HeartsState is the table state of a Hearts game. The cards of the
+// current trick are kept in the game's SharedZone, in the order given by
+// TrickPlayers, and taken tricks are moved to PlayedCards.
+type HeartsState struct {
+	Round         int               `json:"round"`
+	PassDirection PassDirection     `json:"passDirection"`
+	Passed        map[string][]Card `json:"passed"`
+	TrickPlayers  []string          `json:"trickPlayers"`
+	TricksPlayed  int               `json:"tricksPlayed"`
+	HeartsBroken  bool              `json:"heartsBroken"`
+	RoundPoints   map[string]int    `json:"roundPoints"`
+}
+
+// heartsView is the Hearts state sent to a client. Only the viewer's own
+// passed cards are included.
+type heartsView struct {
+	Round         int            `json:"round"`
+	PassDirection PassDirection  `json:"passDirection"`
+	PassedPlayers []string       `json:"passedPlayers"`
+	PassedCards   []Card         `json:"passedCards,omitempty"`
+	Trick         []TrickCard    `json:"trick"`
+	TricksPlayed  int            `json:"tricksPlayed"`
+	HeartsBroken  bool           `json:"heartsBroken"`
+	RoundPoints   map[string]int `json:"roundPoints"`
+}
+
+// HeartsGame is the four player trick-taking game. Each round starts with a
+// passing phase rotating left, right, across and hold, players must follow
+// suit, and every heart taken costs a point and the queen of spades 13.
+// The game ends when a player reaches 100 and the lowest score wins.
+type HeartsGame struct{}
+
+func (HeartsGame) Name() string { return "hearts" }
+
+func (HeartsGame) MinPlayers() int { return heartsPlayers }
+
+func (HeartsGame) MaxPlayers() int { return heartsPlayers }
+
+// Deal starts a new game of Hearts with its first round
+func (HeartsGame) Deal(game *Game) error {
+	if game.Hearts != nil && game.Phase != PhaseFinished {
+		return ErrRoundInProgress
+	}
+
+	for i := range game.Players {
+		game.Players[i].Score = 0
+	}
+	game.Hearts = &HeartsState{}
+
+	return heartsDealRound(game)
+}
+
+// PassCards sets aside the three cards a player passes. Once everyone has
+// passed the cards are exchanged and play starts.
+func (HeartsGame) PassCards(game *Game, player *Player, cards []Card) error {
+	state := game.Hearts
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if len(cards) != heartsPassSize {
+		return errorOf(ErrIllegalMove, "exactly 3 cards must be passed")
+	}
+
+	if _, passed := state.Passed[player.ID]; passed {
+		return errorOf(ErrIllegalMove, "cards already passed")
+	}
+
+	for i, card := range cards {
+		for _, other := range cards[:i] {
+			if card.ID == other.ID {
+				return errorOf(ErrIllegalMove, "the same card cannot be passed twice")
+			}
+		}
+	}
+
+	for _, card := range cards {
+		player.Hand = removeCard(player.Hand, card.ID)
+	}
+	state.Passed[player.ID] = cards
+	game.emit(MsgCardsPassed, CardsPassedData{PlayerID: player.ID})
+
+	if len(state.Passed) < len(game.Players) {
+		return nil
+	}
+
+	// Everyone has passed, hand the cards over
+	offset := heartsPassOffset(state.PassDirection)
+	for i, from := range game.Players {
+		to := &game.Players[(i+offset)%len(game.Players)]
+		to.Hand = append(to.Hand, state.Passed[from.ID]...)
+		game.emitTo(to.ID, MsgCardsReceived, CardsReceivedData{
+			FromPlayerID: from.ID,
+			Cards:        state.Passed[from.ID],
+		})
+	}
+	state.Passed = make(map[string][]Card)
+
+	heartsStartPlay(game)
+	return nil
+}
+
+// TimeoutPass passes the three highest cards of a player who has not
+// passed in time
+func (HeartsGame) TimeoutPass(game *Game, player *Player) error {
+	hand := append([]Card{}, player.Hand...)
+	sort.SliceStable(hand, func(i, j int) bool {
+		return aceHighRank(hand[i]) > aceHighRank(hand[j])
+	})
+	return HeartsGame{}.PassCards(game, player, hand[:min(heartsPassSize, len(hand))])
+}
+
+// ValidatePlay enforces leading the two of clubs, following suit, no points
+// on the first trick and not leading hearts before they are broken
+func (HeartsGame) ValidatePlay(game *Game, player *Player, play Play) error {
+	state := game.Hearts
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	card := play.Card
+	firstTrick := state.TricksPlayed == 0
+
+	// Leading a trick
+	if len(game.SharedZone) == 0 {
+		if firstTrick && !(card.Suit == Clubs && card.Rank == "2") {
+			return errorOf(ErrIllegalCard, "the two of clubs must lead the first trick")
+		}
+		if card.Suit == Hearts && !state.HeartsBroken && !heartsOnlyHearts(player.Hand) {
+			return errorOf(ErrIllegalCard, "hearts have not been broken")
+		}
+		return nil
+	}
+
+	leadSuit := game.SharedZone[0].Suit
+	if card.Suit != leadSuit && hasSuit(player.Hand, leadSuit) {
+		return errorOf(ErrIllegalCard, "you must follow suit")
+	}
+
+	if firstTrick && heartsPoints(card) > 0 && !heartsOnlyPoints(player.Hand) {
+		return errorOf(ErrIllegalCard, "points cannot be played on the first trick")
+	}
+
+	return nil
+}
+
+// ApplyPlay adds the card to the current trick
+func (HeartsGame) ApplyPlay(game *Game, player *Player, play Play) {
+	state := game.Hearts
+	game.SharedZone = append(game.SharedZone, play.Card)
+	state.TrickPlayers = append(state.TrickPlayers, player.ID)
+
+	if play.Card.Suit == Hearts && !state.HeartsBroken {
+		state.HeartsBroken = true
+		game.emit(MsgHeartsBroken, nil)
+	}
+}
+
+// AdvanceTurn passes the turn around the table and resolves the trick once
+// everyone has played. The winner of a trick leads the next one.
+func (HeartsGame) AdvanceTurn(game *Game) {
+	state := game.Hearts
+	if len(game.SharedZone) < len(game.Players) {
+		nextPlayer(game)
+		return
+	}
+
+	// Highest card of the suit led takes the trick
+	winner := 0
+	for i, card := range game.SharedZone {
+		lead := game.SharedZone[winner]
+		if card.Suit == lead.Suit && aceHighRank(card) > aceHighRank(lead) {
+			winner = i
+		}
+	}
+
+	points := 0
+	for _, card := range game.SharedZone {
+		points += heartsPoints(card)
+	}
+
+	winnerID := takeTrick(game, state.TrickPlayers, winner, points)
+	state.RoundPoints[winnerID] += points
+	state.TricksPlayed++
+	state.TrickPlayers = make([]string, 0)
+
+	if state.TricksPlayed < heartsHandSize {
+		return
+	}
+
+	heartsScoreRound(game)
+	if !(HeartsGame{}).IsOver(game) {
+		heartsDealRound(game)
+	}
+}
+
+// IsOver reports whether a player has reached 100 points
+func (HeartsGame) IsOver(game *Game) bool {
+	if game.Hearts == nil {
+		return false
+	}
+
+	for _, player := range game.Players {
+		if player.Score >= heartsGameOver {
+			return true
+		}
+	}
+	return false
+}
+
+// Score does nothing as points are added at the end of every round; the
+// player with the lowest score wins
+func (HeartsGame) Score(game *Game) {}
+
+// ViewState exposes the trick in play and who has passed
+func (HeartsGame) ViewState(game *Game, viewerID string) interface{} {
+	state := game.Hearts
+	if state == nil {
+		return nil
+	}
+
+	view := heartsView{
+		Round:         state.Round,
+		PassDirection: state.PassDirection,
+		PassedPlayers: make([]string, 0, len(state.Passed)),
+		PassedCards:   state.Passed[viewerID],
+		Trick:         trickCards(game, state.TrickPlayers),
+		TricksPlayed:  state.TricksPlayed,
+		HeartsBroken:  state.HeartsBroken,
+		RoundPoints:   state.RoundPoints,
+	}
+
+	for _, player := range game.Players {
+		if _, passed := state.Passed[player.ID]; passed {
+			view.PassedPlayers = append(view.PassedPlayers, player.ID)
+		}
+	}
+
+	return view
+}
+
+// heartsDealRound deals 13 cards to each player from a fresh deck and
+// opens the passing phase, or starts play straight away on hold rounds
+func heartsDealRound(game *Game) error {
+	state := game.Hearts
+	state.Round++
+	state.PassDirection = heartsPassDirection(state.Round)
+	state.Passed = make(map[string][]Card)
+	state.TrickPlayers = make([]string, 0)
+	state.TricksPlayed = 0
+	state.HeartsBroken = false
+	state.RoundPoints = make(map[string]int)
+
+	newDeck(game, createDeck())
+
+	if err := dealHands(game, heartsHandSize); err != nil {
+		return err
+	}
+
+	if state.PassDirection == PassHold {
+		heartsStartPlay(game)
+		return nil
+	}
+
+	game.Phase = PhasePassing
+	setCurrentPlayer(game, "")
+	return nil
+}
+
+// heartsStartPlay gives the lead to the holder of the two of clubs
+func heartsStartPlay(game *Game) {
+	game.Phase = PhasePlaying
+	for _, player := range game.Players {
+		for _, card := range player.Hand {
+			if card.Suit == Clubs && card.Rank == "2" {
+				setCurrentPlayer(game, player.ID)
+				return
+			}
+		}
+	}
+}
+
+// heartsScoreRound adds the points taken this round to the players' scores.
+// A player who took every point shoots the moon and everyone else gets 26.
+func heartsScoreRound(game *Game) {
+	state := game.Hearts
+	shooter := ""
+	for playerID, points := range state.RoundPoints {
+		if points == heartsMoonPoints {
+			shooter = playerID
+		}
+	}
+
+	points := make(map[string]int, len(game.Players))
+	for i, player := range game.Players {
+		switch {
+		case shooter == "":
+			points[player.ID] = state.RoundPoints[player.ID]
+		case player.ID != shooter:
+			points[player.ID] = heartsMoonPoints
+		default:
+			points[player.ID] = 0
+		}
+		game.Players[i].Score += points[player.ID]
+	}
+
+	game.emit(MsgHandScored, HandScoredData{Points: points, ShotTheMoon: shooter})
+}
+
+// heartsPassDirection rotates left, right, across and hold
+func heartsPassDirection(round int) PassDirection {
+	switch (round - 1) % 4 {
+	case 0:
+		return PassLeft
+	case 1:
+		return PassRight
+	case 2:
+		return PassAcross
+	default:
+		return PassHold
+	}
+}
+
+// heartsPassOffset is the seat offset of the player receiving the cards
+func heartsPassOffset(direction PassDirection) int {
+	switch direction {
+	case PassLeft:
+		return 1
+	case PassAcross:
+		return 2
+	case PassRight:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// heartsPoints is the penalty value of a card
+func heartsPoints(card Card) int {
+	switch {
+	case card.Suit == Hearts:
+		return 1
+	case card.Suit == Spades && card.Rank == "Q":
+		return 13
+	default:
+		return 0
+	}
+}
+
+// heartsOnlyHearts reports whether a hand holds nothing but hearts
+func heartsOnlyHearts(hand []Card) bool {
+	for _, card := range hand {
+		if card.Suit != Hearts {
+			return false
+		}
+	}
+	return true
+}
+
+// heartsOnlyPoints reports whether every card in a hand is worth points
+func heartsOnlyPoints(hand []Card) bool {
+	for _, card := range hand {
+		if heartsPoints(card) == 0 {
+			return false
+		}
+	}
+	return true
+}
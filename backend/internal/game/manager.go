@@ -1,35 +1,115 @@
 package game
 
 import (
-	"database/sql"
-	"errors"
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
 )
 
+// SeedSource provides the seeds of new games
+type SeedSource func() int64
+
 type GameManager struct {
 	games map[string]*Game
 	mutex sync.RWMutex
-	db    *sql.DB
+	store GameStore
+	// writer saves the games to the store outside the lock
+	writer *storeWriter
+	seeds  SeedSource
+	// onUpdate is told about games changed by the manager's timers
+	onUpdate UpdateHandler
+	// seats holds where the latest seat request of each client session led
+	seats map[string]seatRecord
 }
 
-func NewGameManager(db *sql.DB) *GameManager {
+// NewGameManager creates a manager that saves its games to the given
+// store, keeping them in memory when there is none
+func NewGameManager(store GameStore) *GameManager {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
 	return &GameManager{
-		games: make(map[string]*Game),
-		mutex: sync.RWMutex{},
-		db:    db,
+		games:  make(map[string]*Game),
+		mutex:  sync.RWMutex{},
+		store:  store,
+		writer: newStoreWriter(store),
+		seeds:  randomSeed,
+		seats:  make(map[string]seatRecord),
 	}
 }
 
-// CreateGame creates a new game
-func (gm *GameManager) CreateGame() *Game {
+// SetSeedSource replaces where the seeds of new games come from, so tests
+// and daily challenges can deal known decks
+func (gm *GameManager) SetSeedSource(seeds SeedSource) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
-	game := NewGame()
+	gm.seeds = seeds
+}
+
+// CreateGame creates a new game with the given table options. A seat
+// request that has created a game already gets that game back along with
+// ErrDuplicateRequest.
+func (gm *GameManager) CreateGame(options GameOptions, seat SeatRequest) (*Game, error) {
+	gm.mutex.RLock()
+	seed := gm.seeds()
+	gm.mutex.RUnlock()
+
+	return gm.createGame(options, seed, seat)
+}
+
+// CreateGameWithSeed creates a new game whose shuffles all derive from the
+// given seed, reproducing the deals of any game created with it
+func (gm *GameManager) CreateGameWithSeed(options GameOptions, seed int64) (*Game, error) {
+	return gm.createGame(options, seed, SeatRequest{})
+}
+
+func (gm *GameManager) createGame(options GameOptions, seed int64, seat SeatRequest) (*Game, error) {
+	game, err := newGameWithOptions(options, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	if record, ok := gm.seatFor(seat); ok {
+		if created, exists := gm.games[record.gameID]; exists {
+			return created, ErrDuplicateRequest
+		}
+	}
+
 	gm.games[game.ID] = game
-	return game
+	gm.recordSeat(seat, game.ID, "")
+	gm.saveGame(game)
+	return game, nil
+}
+
+// newGameWithOptions checks the options against their ruleset and creates
+// the game, drawing the server seed of provably fair games
+func newGameWithOptions(options GameOptions, seed int64) (*Game, error) {
+	rules, err := GetRuleSet(options.RuleSet)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err = resolveOptions(rules, options)
+	if err != nil {
+		return nil, err
+	}
+
+	game := NewGame(options, seed)
+	if options.ProvablyFair {
+		game.Fairness, err = newFairShuffle(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return game, nil
 }
 
 // GetGame retrieves a game by ID
@@ -39,38 +119,120 @@ func (gm *GameManager) GetGame(gameID string) (*Game, error) {
 
 	game, exists := gm.games[gameID]
 	if !exists {
-		return nil, errors.New("game not found")
+		return nil, ErrGameNotFound
 	}
 	return game, nil
 }
 
-// JoinGame adds a player to a game
-func (gm *GameManager) JoinGame(gameID, playerName string) (*Game, *Player, error) {
+// JoinGame adds a player to a game. The ruleset is only used when the
+// game does not exist yet and has to be created, and the client seed only
+// in provably fair games. A seat request that has seated a player already
+// gets that player back along with ErrDuplicateRequest.
+func (gm *GameManager) JoinGame(gameID, playerName, ruleSet, clientSeed string, seat SeatRequest) (*Game, *Player, error) {
+	// The store only holds IDs and names up to a length
+	if err := checkLength("gameId", gameID, maxGameIDLength); err != nil {
+		return nil, nil, err
+	}
+	if err := checkLength("playerName", playerName, maxPlayerNameLength); err != nil {
+		return nil, nil, err
+	}
+
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
+	if game, player, ok := gm.seatTaken(seat); ok {
+		return game, player, ErrDuplicateRequest
+	}
+
 	game, exists := gm.games[gameID]
 	if !exists {
 		// Create new game if it doesn't exist
-		game = NewGame()
+		var err error
+		game, err = newGameWithOptions(GameOptions{RuleSet: ruleSet}, gm.seeds())
+		if err != nil {
+			return nil, nil, err
+		}
 		game.ID = gameID
 		gm.games[gameID] = game
 	}
 
-	// Check if game is full (max 4 players for this card game)
-	if len(game.Players) >= 4 {
-		return nil, nil, errors.New("game is full")
+	player, err := gm.seatPlayer(game, playerName, clientSeed, seat)
+	if err != nil {
+		// A game created for the player is not kept without them
+		if !exists {
+			delete(gm.games, gameID)
+		}
+		return nil, nil, err
+	}
+	return game, player, nil
+}
+
+// HostGame creates a game with the given table options and seats the
+// player who created it. A game whose creator cannot be seated is never
+// kept. A seat request that has seated a player already gets that player
+// back along with ErrDuplicateRequest.
+func (gm *GameManager) HostGame(options GameOptions, playerName, clientSeed string, seat SeatRequest) (*Game, *Player, error) {
+	if err := checkLength("playerName", playerName, maxPlayerNameLength); err != nil {
+		return nil, nil, err
+	}
+
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	if game, player, ok := gm.seatTaken(seat); ok {
+		return game, player, ErrDuplicateRequest
+	}
+
+	game, err := newGameWithOptions(options, gm.seeds())
+	if err != nil {
+		return nil, nil, err
+	}
+	gm.games[game.ID] = game
+
+	player, err := gm.seatPlayer(game, playerName, clientSeed, seat)
+	if err != nil {
+		delete(gm.games, game.ID)
+		return nil, nil, err
+	}
+	return game, player, nil
+}
+
+// seatTaken returns the player a seat request has seated already
+func (gm *GameManager) seatTaken(seat SeatRequest) (*Game, *Player, bool) {
+	record, ok := gm.seatFor(seat)
+	if !ok || record.playerID == "" {
+		return nil, nil, false
+	}
+
+	game, exists := gm.games[record.gameID]
+	if !exists {
+		return nil, nil, false
+	}
+	playerIndex := findPlayer(game, record.playerID)
+	if playerIndex == -1 {
+		return nil, nil, false
+	}
+	player := game.Players[playerIndex]
+	return game, &player, true
+}
+
+// seatPlayer adds a new player to a game and saves it
+func (gm *GameManager) seatPlayer(game *Game, playerName, clientSeed string, seat SeatRequest) (*Player, error) {
+	// Check if game is full
+	if len(game.Players) >= game.Options.MaxPlayers {
+		return nil, ErrGameFull
 	}
 
 	// Check if player name already exists
 	for _, player := range game.Players {
 		if player.Name == playerName {
-			return nil, nil, errors.New("player name already exists")
+			return nil, ErrNameTaken
 		}
 	}
 
 	// Create new player
 	player := NewPlayer(playerName)
+	player.TimeBank = time.Duration(game.Options.TimeBank) * time.Second
 	
 	// Don't deal cards automatically - wait for deal cards message
 	player.Hand = make([]Card, 0)
@@ -78,19 +240,28 @@ func (gm *GameManager) JoinGame(gameID, playerName string) (*Game, *Player, erro
 	// Add player to game
 	game.Players = append(game.Players, player)
 
+	if game.Fairness != nil {
+		game.Fairness.AddClientSeed(player.ID, clientSeed)
+	}
+
 	// Set as current player if first player
 	if len(game.Players) == 1 {
 		game.CurrentPlayer = player.ID
 		player.IsCurrentPlayer = true
+		game.turn++
 	}
 
 	// Start game if we have enough players
-	if len(game.Players) >= 2 && game.Phase == PhaseWaiting {
+	if len(game.Players) >= game.Options.MinPlayers && game.Phase == PhaseWaiting {
 		game.Phase = PhasePlaying
 	}
 
-	game.UpdatedAt = time.Now()
-	return game, &player, nil
+	gm.recordSeat(seat, game.ID, player.ID)
+
+	if err := gm.touch(game); err != nil {
+		return nil, err
+	}
+	return &player, nil
 }
 
 // LeaveGame removes a player from a game
@@ -100,122 +271,692 @@ func (gm *GameManager) LeaveGame(gameID, playerID string) (*Game, error) {
 
 	game, exists := gm.games[gameID]
 	if !exists {
-		return nil, errors.New("game not found")
+		return nil, ErrGameNotFound
 	}
 
 	// Find and remove player
-	playerIndex := -1
-	for i, player := range game.Players {
-		if player.ID == playerID {
-			playerIndex = i
-			break
-		}
+	playerIndex := findPlayer(game, playerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
 	}
 
-	if playerIndex == -1 {
-		return nil, errors.New("player not found in game")
+	kept, err := gm.removePlayer(game, playerIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !kept {
+		return nil, nil
+	}
+	return game, nil
+}
+
+// removePlayer takes a player out of a game. It reports false when the
+// game was left empty and has been deleted.
+func (gm *GameManager) removePlayer(game *Game, playerIndex int) (bool, error) {
+	playerID := game.Players[playerIndex].ID
+	rules := rulesFor(game)
+
+	// The ruleset settles the player's part in the hand in play, and a turn
+	// that was theirs passes to the next seat
+	playing := game.Phase == PhasePlaying || game.Phase == PhasePaused && game.PausedPhase == PhasePlaying
+	if leaver, ok := rules.(Leaver); ok && playing {
+		leaver.Leave(game, &game.Players[playerIndex])
+	}
+	if game.CurrentPlayer == playerID {
+		nextPlayer(game)
 	}
 
-	// Remove player
+	// The player's cards go under the discard pile so none leave the game
+	hand := game.Players[playerIndex].Hand
+	game.PlayedCards = append(append(make([]Card, 0, len(hand)+len(game.PlayedCards)), hand...), game.PlayedCards...)
 	game.Players = append(game.Players[:playerIndex], game.Players[playerIndex+1:]...)
 
-	// Update current player if necessary
-	if game.CurrentPlayer == playerID && len(game.Players) > 0 {
-		game.CurrentPlayer = game.Players[0].ID
-		game.Players[0].IsCurrentPlayer = true
+	if timer, ok := game.graceTimers[playerID]; ok {
+		timer.Stop()
+		delete(game.graceTimers, playerID)
+	}
+	delete(game.requests, playerID)
+
+	// The player may have been the last one play was waiting for
+	resumeAfterReconnect(game)
+
+	// Leaving may have ended the game
+	if game.Phase == PhasePlaying {
+		finishIfOver(game, rules)
 	}
 
-	// End game if not enough players
-	if len(game.Players) < 2 && game.Phase == PhasePlaying {
+	// End game if not enough players, a round in play cannot be finished
+	inRound := game.Phase == PhasePlaying || game.Phase == PhasePassing || game.Phase == PhasePaused
+	if len(game.Players) < game.Options.MinPlayers && inRound {
 		game.Phase = PhaseWaiting
+		game.PausedPhase = ""
+		game.reconnectPause = false
+		abandonRound(game)
 	}
 
 	// Delete game if no players left
 	if len(game.Players) == 0 {
-		delete(gm.games, gameID)
-		return nil, nil
+		stopTimers(game)
+		delete(gm.games, game.ID)
+		gm.forgetSeats(game.ID)
+		gm.deleteGame(game.ID)
+		return false, nil
 	}
 
-	game.UpdatedAt = time.Now()
-	return game, nil
+	if err := gm.touch(game); err != nil {
+		return true, err
+	}
+	return true, nil
 }
 
-// PlayCard handles a player playing a card
-func (gm *GameManager) PlayCard(gameID, playerID string, card Card) (*Game, error) {
+// PlayCard handles a player playing a card from their hand, named by its
+// ID. The declared suit is only used by rulesets with wild cards.
+func (gm *GameManager) PlayCard(request Request, cardID string, declaredSuit Suit) (*Game, error) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, errors.New("game not found")
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
 	}
 
 	if game.Phase != PhasePlaying {
-		return nil, errors.New("game is not in playing phase")
+		return nil, ErrNotPlaying
 	}
 
-	if game.CurrentPlayer != playerID {
-		return nil, errors.New("not your turn")
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
 	}
 
 	// Find player
 	playerIndex := -1
 	for i, player := range game.Players {
-		if player.ID == playerID {
+		if player.ID == request.PlayerID {
 			playerIndex = i
 			break
 		}
 	}
 
 	if playerIndex == -1 {
-		return nil, errors.New("player not found")
+		return nil, ErrPlayerNotFound
 	}
 
-	// Find and remove card from player's hand
-	cardIndex := -1
-	for i, handCard := range game.Players[playerIndex].Hand {
-		if handCard.ID == card.ID {
-			cardIndex = i
-			break
+	// Find the card in the player's hand, the server's copy is the one played
+	player := &game.Players[playerIndex]
+	cardIndex := findCard(player.Hand, cardID)
+	if cardIndex == -1 {
+		return nil, ErrCardNotInHand
+	}
+
+	// Check the move against the game's rules
+	rules := rulesFor(game)
+	play := Play{Card: player.Hand[cardIndex], DeclaredSuit: declaredSuit}
+	if err := rules.ValidatePlay(game, player, play); err != nil {
+		return nil, err
+	}
+
+	// Put the card on the table and move to next player
+	game.emit(MsgCardPlayed, CardPlayedData{PlayerID: player.ID, Card: play.Card})
+	applyPlay(game, rules, player, play)
+
+	// Check for game end condition
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// DrawCard lets the current player draw from the deck, or from the discard
+// pile, when the ruleset allows it
+func (gm *GameManager) DrawCard(request Request, fromDiscard bool) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	rules := rulesFor(game)
+	player := &game.Players[playerIndex]
+
+	if fromDiscard {
+		drawer, ok := rules.(DiscardDrawer)
+		if !ok {
+			return nil, errorOf(ErrActionNotAllowed, "drawing from the discard pile is not allowed in this game")
+		}
+		if err := drawer.DrawDiscard(game, player); err != nil {
+			return nil, err
+		}
+	} else {
+		drawer, ok := rules.(Drawer)
+		if !ok {
+			return nil, errorOf(ErrActionNotAllowed, "drawing cards is not allowed in this game")
 		}
+		if err := drawer.Draw(game, player); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// DiscardCard lets the current player put a card from their hand on the
+// discard pile when the ruleset allows it
+func (gm *GameManager) DiscardCard(request Request, cardID string) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	rules := rulesFor(game)
+	discarder, ok := rules.(Discarder)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "discarding is not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
 	}
 
+	player := &game.Players[playerIndex]
+	cardIndex := findCard(player.Hand, cardID)
 	if cardIndex == -1 {
-		return nil, errors.New("card not found in player's hand")
+		return nil, ErrCardNotInHand
 	}
 
-	// Remove card from hand
-	game.Players[playerIndex].Hand = append(
-		game.Players[playerIndex].Hand[:cardIndex],
-		game.Players[playerIndex].Hand[cardIndex+1:]...,
-	)
+	if err := discarder.Discard(game, player, player.Hand[cardIndex]); err != nil {
+		return nil, err
+	}
 
-	// Add to played cards and shared zone
-	game.PlayedCards = append(game.PlayedCards, card)
-	game.SharedZone = append(game.SharedZone, card)
+	// Check for game end condition
+	finishIfOver(game, rules)
 
-	// Move to next player
-	gm.nextPlayer(game)
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// Knock ends the round for the current player with a final discard
+func (gm *GameManager) Knock(request Request, cardID string) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	knocker, ok := rulesFor(game).(Knocker)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "knocking is not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	player := &game.Players[playerIndex]
+	cardIndex := findCard(player.Hand, cardID)
+	if cardIndex == -1 {
+		return nil, ErrCardNotInHand
+	}
+
+	if err := knocker.Knock(game, player, player.Hand[cardIndex]); err != nil {
+		return nil, err
+	}
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// EndTurn finishes the current player's turn when the ruleset needs it
+func (gm *GameManager) EndTurn(request Request) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	rules := rulesFor(game)
+	ender, ok := rules.(TurnEnder)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "ending the turn is not needed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if err := ender.EndTurn(game, &game.Players[playerIndex]); err != nil {
+		return nil, err
+	}
 
 	// Check for game end condition
-	if len(game.Players[playerIndex].Hand) == 0 {
-		game.Phase = PhaseFinished
-		game.Players[playerIndex].Score += 100 // Winner bonus
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// PassCards hands the cards a player passes before a round to the ruleset
+func (gm *GameManager) PassCards(request Request, cardIDs []string) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePassing {
+		return nil, ErrNotPassing
+	}
+
+	passer, ok := rulesFor(game).(Passer)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "passing cards is not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	// Resolve the cards against the player's own hand
+	player := &game.Players[playerIndex]
+	passed := make([]Card, 0, len(cardIDs))
+	for _, cardID := range cardIDs {
+		handIndex := findCard(player.Hand, cardID)
+		if handIndex == -1 {
+			return nil, ErrCardNotInHand
+		}
+		passed = append(passed, player.Hand[handIndex])
+	}
+
+	if err := passer.PassCards(game, player, passed); err != nil {
+		return nil, err
 	}
 
-	game.UpdatedAt = time.Now()
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
 	return game, nil
 }
 
+// findCard returns the index of a card in a set of cards, or -1
+func findCard(cards []Card, cardID string) int {
+	for i, card := range cards {
+		if card.ID == cardID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Bet applies a betting action of the current player
+func (gm *GameManager) Bet(request Request, action BetAction, amount int) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	rules := rulesFor(game)
+	bettor, ok := rules.(Bettor)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "betting is not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if err := bettor.Bet(game, &game.Players[playerIndex], action, amount); err != nil {
+		return nil, err
+	}
+
+	// Check for game end condition
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// PlaceBet sets a player's bet for the next round
+func (gm *GameManager) PlaceBet(request Request, amount int) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	wagerer, ok := rulesFor(game).(Wagerer)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "bets are not placed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if err := wagerer.PlaceBet(game, &game.Players[playerIndex], amount); err != nil {
+		return nil, err
+	}
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// Sing lets the current player declare cards held in hand for points
+func (gm *GameManager) Sing(request Request, suit Suit) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	rules := rulesFor(game)
+	singer, ok := rules.(Singer)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "singing is not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if err := singer.Sing(game, &game.Players[playerIndex], suit); err != nil {
+		return nil, err
+	}
+
+	// Check for game end condition
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// Call makes or answers a call. Answers come from the other side, so the
+// ruleset checks whose turn it is.
+func (gm *GameManager) Call(request Request, call TrucoCall) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	rules := rulesFor(game)
+	caller, ok := rules.(Caller)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "calls are not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if err := caller.Call(game, &game.Players[playerIndex], call); err != nil {
+		return nil, err
+	}
+
+	// Check for game end condition
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// HandAction applies an action of the current player on their hand
+func (gm *GameManager) HandAction(request Request, action HandAction) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
+	}
+
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
+	}
+
+	rules := rulesFor(game)
+	actor, ok := rules.(HandActor)
+	if !ok {
+		return nil, errorOf(ErrActionNotAllowed, "hand actions are not allowed in this game")
+	}
+
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if err := actor.HandAction(game, &game.Players[playerIndex], action); err != nil {
+		return nil, err
+	}
+
+	// Check for game end condition
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// pausesPerPlayer is how many times each player may pause a game
+const pausesPerPlayer = 3
+
+// PauseGame stops play, along with the players' clocks and turn timers,
+// until the game is resumed. Only the player to act can pause a game in
+// play, a limited number of times.
+func (gm *GameManager) PauseGame(request Request) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if findPlayer(game, request.PlayerID) == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if game.Phase != PhasePlaying && game.Phase != PhasePassing {
+		return nil, errorOf(ErrNotPlaying, "only a game in play can be paused")
+	}
+
+	if game.CurrentPlayer != "" && game.CurrentPlayer != request.PlayerID {
+		return nil, errorOf(ErrNotYourTurn, "only the player to act can pause the game")
+	}
+
+	if game.Pauses[request.PlayerID] >= pausesPerPlayer {
+		return nil, errorOf(ErrActionNotAllowed, "no pauses left")
+	}
+
+	if game.Pauses == nil {
+		game.Pauses = make(map[string]int)
+	}
+	game.Pauses[request.PlayerID]++
+	game.PausedPhase = game.Phase
+	game.Phase = PhasePaused
+	game.emit(MsgGamePaused, GamePausedData{PlayerID: request.PlayerID, PausesLeft: pausesPerPlayer - game.Pauses[request.PlayerID]})
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// ResumeGame continues a paused game where it left off, once every player
+// is connected
+func (gm *GameManager) ResumeGame(request Request) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if findPlayer(game, request.PlayerID) == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	if game.Phase != PhasePaused {
+		return nil, ErrGameNotPaused
+	}
+
+	for _, player := range game.Players {
+		if !player.Connected {
+			return nil, ErrPlayerDisconnected
+		}
+	}
+
+	game.Phase = game.PausedPhase
+	game.PausedPhase = ""
+	game.reconnectPause = false
+	game.emit(MsgGameResumed, GamePausedData{PlayerID: request.PlayerID})
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// findPlayer returns the index of a player in the game, or -1
+func findPlayer(game *Game, playerID string) int {
+	for i, player := range game.Players {
+		if player.ID == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeCard returns the cards without the one with the given ID
+func removeCard(cards []Card, cardID string) []Card {
+	index := findCard(cards, cardID)
+	if index == -1 {
+		return cards
+	}
+	return append(cards[:index], cards[index+1:]...)
+}
+
 // dealCards deals a specified number of cards from the deck
-func (gm *GameManager) dealCards(game *Game, count int) ([]Card, error) {
+func dealCards(game *Game, count int) ([]Card, error) {
 	if len(game.Deck) < count {
-		return nil, errors.New("not enough cards in deck")
+		refillDeck(game)
+	}
+
+	if len(game.Deck) < count {
+		return nil, ErrDeckEmpty
 	}
 
 	// Shuffle deck if needed
 	if len(game.PlayedCards) == 0 {
-		gm.shuffleDeck(game.Deck)
+		shuffleDeck(game, game.Deck)
 	}
 
 	// Deal cards
@@ -226,17 +967,96 @@ func (gm *GameManager) dealCards(game *Game, count int) ([]Card, error) {
 	return cards, nil
 }
 
-// shuffleDeck shuffles the game deck
-func (gm *GameManager) shuffleDeck(deck []Card) {
-	rand.Seed(time.Now().UnixNano())
+// dealHands deals count cards to every player. It fails before any card is
+// dealt when the deck and the discard pile cannot cover every hand, so a
+// failed deal leaves the hands as they were.
+func dealHands(game *Game, count int) error {
+	needed := count * len(game.Players)
+	if len(game.Deck) < needed {
+		refillDeck(game)
+	}
+
+	if len(game.Deck) < needed {
+		return ErrDeckEmpty
+	}
+
+	for i := range game.Players {
+		hand, err := dealCards(game, count)
+		if err != nil {
+			return err
+		}
+		game.Players[i].Hand = hand
+	}
+	return nil
+}
+
+// refillDeck shuffles the discard pile in PlayedCards, except its top
+// card, back into the deck. It reports whether any cards were added.
+func refillDeck(game *Game) bool {
+	if len(game.PlayedCards) <= 1 {
+		return false
+	}
+
+	top := game.PlayedCards[len(game.PlayedCards)-1]
+	reshuffled := game.PlayedCards[:len(game.PlayedCards)-1]
+	shuffleDeck(game, reshuffled)
+
+	game.Deck = append(game.Deck, reshuffled...)
+	game.PlayedCards = []Card{top}
+	game.emit(MsgDeckReshuffled, DeckReshuffledData{Count: len(reshuffled), DeckCount: len(game.Deck)})
+	return true
+}
+
+// shuffleDeck shuffles cards with the game's random source. Each shuffle
+// gets its own source derived from the game seed and the number of
+// shuffles before it, so a deal can be reproduced from the seed alone. In
+// provably fair games the seed comes from the server and client seeds and
+// every shuffle is committed to before the cards are dealt.
+func shuffleDeck(game *Game, deck []Card) {
+	fairness := game.Fairness
+	if fairness != nil && len(fairness.Commitments) == 0 {
+		game.Seed = fairness.GameSeed()
+	}
+
+	shuffle := game.Shuffles
+	game.Shuffles++
+	shuffleCards(rand.New(rand.NewSource(shuffleSeed(game.Seed, shuffle))), deck)
+
+	if fairness != nil {
+		commitment := ShuffleCommitment{Shuffle: shuffle, Hash: deckHash(fairness.ServerSeed, deck)}
+		fairness.Commitments = append(fairness.Commitments, commitment)
+		game.emit(MsgShuffleCommitted, commitment)
+	}
+}
+
+// shuffleCards is a Fisher-Yates shuffle of the cards
+func shuffleCards(rng *rand.Rand, deck []Card) {
 	for i := len(deck) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := rng.Intn(i + 1)
 		deck[i], deck[j] = deck[j], deck[i]
 	}
 }
 
+// shuffleSeed mixes the game seed with a shuffle number (splitmix64)
+func shuffleSeed(seed int64, shuffle int) int64 {
+	z := uint64(seed) + uint64(shuffle+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// randomSeed is the default seed source, falling back to the clock if the
+// system has no randomness to offer
+func randomSeed() int64 {
+	var buf [8]byte
+	if _, err := cryptorand.Read(buf[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(buf[:]))
+}
+
 // nextPlayer moves to the next player
-func (gm *GameManager) nextPlayer(game *Game) {
+func nextPlayer(game *Game) {
 	if len(game.Players) <= 1 {
 		return
 	}
@@ -259,42 +1079,75 @@ func (gm *GameManager) nextPlayer(game *Game) {
 	nextIndex := (currentIndex + 1) % len(game.Players)
 	game.CurrentPlayer = game.Players[nextIndex].ID
 	game.Players[nextIndex].IsCurrentPlayer = true
+	game.turn++
 }
 
-// ListGames returns all active games
+// setCurrentPlayer hands the turn to the given player
+func setCurrentPlayer(game *Game, playerID string) {
+	game.CurrentPlayer = playerID
+	game.turn++
+	for i := range game.Players {
+		game.Players[i].IsCurrentPlayer = game.Players[i].ID == playerID
+	}
+}
+
+// ListGames returns all active public games
 func (gm *GameManager) ListGames() []*Game {
 	gm.mutex.RLock()
 	defer gm.mutex.RUnlock()
 
 	games := make([]*Game, 0, len(gm.games))
 	for _, game := range gm.games {
-		games = append(games, game)
+		if !game.Options.Private {
+			games = append(games, game)
+		}
 	}
 	return games
 }
 
 // DealCards deals cards to all players in a game
-func (gm *GameManager) DealCards(gameID string) (*Game, error) {
+func (gm *GameManager) DealCards(request Request) (*Game, error) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, errors.New("game not found")
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(game.Players) < 2 {
-		return nil, errors.New("need at least 2 players to deal cards")
+	rules := rulesFor(game)
+	if len(game.Players) < game.Options.MinPlayers {
+		return nil, errorOf(ErrNotEnoughPlayers, fmt.Sprintf("need at least %d players to deal cards", game.Options.MinPlayers))
 	}
 
-	// Deal 5 cards to each player
-	cardsPerPlayer := 5
-	for i := range game.Players {
-		hand, err := gm.dealCards(game, cardsPerPlayer)
+	if game.Phase == PhasePaused {
+		return nil, ErrGamePaused
+	}
+
+	// Clocks and pauses start again with every new game
+	if game.Phase == PhaseFinished {
+		resetTimeBanks(game)
+		game.Pauses = nil
+	}
+
+	// A finished fair game has revealed its seed, so a new game needs a
+	// new one
+	if game.Fairness != nil && game.Phase == PhaseFinished {
+		fairness, err := newFairShuffle(game.Fairness.ClientSeeds)
 		if err != nil {
 			return nil, err
 		}
-		game.Players[i].Hand = hand
+		game.Fairness = fairness
+	}
+
+	// Deal the hands as the ruleset requires. Rulesets played in rounds
+	// start a new match unless one is in progress.
+	if _, ok := rules.(RoundScorer); ok && (game.Match == nil || game.Phase == PhaseFinished) {
+		if err := startMatch(game, rules); err != nil {
+			return nil, err
+		}
+	} else if err := rules.Deal(game); err != nil {
+		return nil, err
 	}
 
 	// Start the game if it was waiting
@@ -302,62 +1155,119 @@ func (gm *GameManager) DealCards(gameID string) (*Game, error) {
 		game.Phase = PhasePlaying
 	}
 
-	game.UpdatedAt = time.Now()
+	// Some rounds are settled as soon as they are dealt
+	finishIfOver(game, rules)
+
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
 	return game, nil
 }
 
-// DropCardInSharedZone moves a card to the shared zone
-func (gm *GameManager) DropCardInSharedZone(gameID, playerID string, card Card, position Position) (*Game, error) {
+// DropCardInSharedZone moves cards from the player's hand, named by their
+// IDs, to the shared zone. Rulesets with melds lay them down as a new meld,
+// or lay them off onto meldID.
+func (gm *GameManager) DropCardInSharedZone(request Request, cardIDs []string, position Position, meldID string) (*Game, error) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, errors.New("game not found")
+	game, err := gm.begin(request)
+	if err != nil {
+		return nil, err
 	}
 
-	// Find player
-	playerIndex := -1
-	for i, player := range game.Players {
-		if player.ID == playerID {
-			playerIndex = i
-			break
-		}
+	// Other rulesets keep the shared zone for their own cards, such as the
+	// trick or the community cards
+	rules := rulesFor(game)
+	melder, melds := rules.(Melder)
+	dropper, drops := rules.(Dropper)
+	if !melds && !drops {
+		return nil, errorOf(ErrActionNotAllowed, "cards cannot be dropped in this game")
+	}
+	if !melds && meldID != "" {
+		return nil, errorOf(ErrActionNotAllowed, "melds are not used in this game")
 	}
 
-	if playerIndex == -1 {
-		return nil, errors.New("player not found")
+	// Drops and melds alike are moves of the current player
+	if game.Phase != PhasePlaying {
+		return nil, ErrNotPlaying
 	}
 
-	// Find and remove card from player's hand
-	cardIndex := -1
-	for i, handCard := range game.Players[playerIndex].Hand {
-		if handCard.ID == card.ID {
-			cardIndex = i
-			break
-		}
+	if game.CurrentPlayer != request.PlayerID {
+		return nil, ErrNotYourTurn
 	}
 
-	if cardIndex == -1 {
-		return nil, errors.New("card not found in player's hand")
+	playerIndex := findPlayer(game, request.PlayerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
 	}
 
-	// Remove card from hand
-	game.Players[playerIndex].Hand = append(
-		game.Players[playerIndex].Hand[:cardIndex],
-		game.Players[playerIndex].Hand[cardIndex+1:]...,
-	)
+	if len(cardIDs) == 0 {
+		return nil, &PayloadError{Field: "cardIds", Message: "must name at least one card"}
+	}
 
-	// Add to shared zone
-	game.SharedZone = append(game.SharedZone, card)
+	// Resolve the cards against the player's own hand
+	player := &game.Players[playerIndex]
+	dropped := make([]Card, 0, len(cardIDs))
+	for _, cardID := range cardIDs {
+		cardIndex := findCard(player.Hand, cardID)
+		if cardIndex == -1 || findCard(dropped, cardID) != -1 {
+			return nil, ErrCardNotInHand
+		}
+		dropped = append(dropped, player.Hand[cardIndex])
+	}
+
+	// Rulesets with melds validate and place the cards themselves
+	switch {
+	case melds && meldID == "":
+		err = melder.LayDown(game, player, dropped)
+	case melds:
+		err = melder.LayOff(game, player, meldID, dropped)
+	default:
+		err = dropper.Drop(game, player, dropped)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	game.UpdatedAt = time.Now()
+	emitCardsDropped(game, player.ID, dropped, position, meldID)
+	if err := gm.commit(game, request); err != nil {
+		return nil, err
+	}
 	return game, nil
 }
 
+// emitCardsDropped announces the cards a player moved to the shared zone
+func emitCardsDropped(game *Game, playerID string, cards []Card, position Position, meldID string) {
+	game.emit(MsgCardDropped, CardDroppedData{
+		PlayerID: playerID,
+		Card:     cards[0],
+		Cards:    cards,
+		MeldID:   meldID,
+		Position: position,
+	})
+}
+
 // CleanupGame removes a game from memory
 func (gm *GameManager) CleanupGame(gameID string) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
+	if game, exists := gm.games[gameID]; exists {
+		stopTimers(game)
+	}
 	delete(gm.games, gameID)
-}
\ No newline at end of file
+	gm.forgetSeats(gameID)
+	gm.deleteGame(gameID)
+}
+
+// GameView returns the state of a game as seen by the given player
+func (gm *GameManager) GameView(gameID, viewerID string) (GameView, error) {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		return GameView{}, ErrGameNotFound
+	}
+	return NewGameView(game, viewerID), nil
+}
@@ -0,0 +1,694 @@
+package game
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestGame creates a game with a fixed seed and seats the named players
+func newTestGame(t *testing.T, options GameOptions, names ...string) (*GameManager, *Game, []string) {
+	t.Helper()
+
+	gm := NewGameManager(nil)
+	game, err := gm.CreateGameWithSeed(options, 1)
+	if err != nil {
+		t.Fatalf("creating game: %v", err)
+	}
+
+	playerIDs := make([]string, 0, len(names))
+	for _, name := range names {
+		_, player, err := gm.JoinGame(game.ID, name, "", "", SeatRequest{})
+		if err != nil {
+			t.Fatalf("joining %s: %v", name, err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	return gm, game, playerIDs
+}
+
+// requestFor is a request of a player without an ID or expected version
+func requestFor(game *Game, playerID string) Request {
+	return Request{GameID: game.ID, PlayerID: playerID}
+}
+
+func TestJoinGame(t *testing.T) {
+	tests := []struct {
+		name       string
+		gameID     string
+		playerName string
+		ruleSet    string
+		want       error
+		wantField  string
+	}{
+		{name: "seats a new player", playerName: "carol"},
+		{name: "rejects a taken name", playerName: "alice", want: ErrNameTaken},
+		{name: "rejects a long name", playerName: strings.Repeat("é", maxPlayerNameLength+1), wantField: "playerName"},
+		{name: "rejects a long game ID", gameID: strings.Repeat("x", maxGameIDLength+1), playerName: "carol", wantField: "gameId"},
+		{name: "rejects an unknown ruleset", gameID: "new-table", playerName: "carol", ruleSet: "snap", want: ErrUnknownRuleSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm, game, _ := newTestGame(t, GameOptions{}, "alice", "bob")
+			gameID := tt.gameID
+			if gameID == "" {
+				gameID = game.ID
+			}
+
+			_, player, err := gm.JoinGame(gameID, tt.playerName, tt.ruleSet, "", SeatRequest{})
+
+			var payloadErr *PayloadError
+			switch {
+			case tt.wantField != "":
+				if !errors.As(err, &payloadErr) || payloadErr.Field != tt.wantField {
+					t.Fatalf("got %v, want a payload error on %s", err, tt.wantField)
+				}
+			case tt.want != nil:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+			case err != nil:
+				t.Fatalf("got %v", err)
+			case player.Name != tt.playerName || len(game.Players) != 3:
+				t.Fatalf("player %q not seated", tt.playerName)
+			}
+		})
+	}
+}
+
+func TestJoinFullGame(t *testing.T) {
+	gm, game, _ := newTestGame(t, GameOptions{MaxPlayers: 2}, "alice", "bob")
+	if _, _, err := gm.JoinGame(game.ID, "carol", "", "", SeatRequest{}); !errors.Is(err, ErrGameFull) {
+		t.Fatalf("got %v, want %v", err, ErrGameFull)
+	}
+}
+
+func TestDuplicateRequest(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{}, "alice", "bob")
+
+	deal := Request{GameID: game.ID, PlayerID: playerIDs[0], ID: "deal-1"}
+	if _, err := gm.DealCards(deal); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+	version := game.Version
+	if _, err := gm.DealCards(deal); !errors.Is(err, ErrDuplicateRequest) {
+		t.Fatalf("got %v, want %v", err, ErrDuplicateRequest)
+	}
+	if game.Version != version {
+		t.Fatalf("duplicate changed the game to version %d", game.Version)
+	}
+
+	// A request that fails is not recorded and can be sent again
+	play := Request{GameID: game.ID, PlayerID: playerIDs[0], ID: "play-1"}
+	for i := 0; i < 2; i++ {
+		if _, err := gm.PlayCard(play, "no-such-card", ""); !errors.Is(err, ErrCardNotInHand) {
+			t.Fatalf("attempt %d: got %v, want %v", i+1, err, ErrCardNotInHand)
+		}
+	}
+}
+
+func TestSeatRequest(t *testing.T) {
+	gm := NewGameManager(nil)
+	seat := SeatRequest{SessionKey: "session", ID: "create-1"}
+
+	created, err := gm.CreateGame(GameOptions{}, seat)
+	if err != nil {
+		t.Fatalf("creating: %v", err)
+	}
+	_, player, err := gm.JoinGame(created.ID, "alice", "", "", seat)
+	if err != nil {
+		t.Fatalf("joining: %v", err)
+	}
+
+	retried, err := gm.CreateGame(GameOptions{}, seat)
+	if !errors.Is(err, ErrDuplicateRequest) || retried.ID != created.ID {
+		t.Fatalf("retried create: got %v, want the first game", err)
+	}
+	_, rejoined, err := gm.JoinGame(created.ID, "alice", "", "", seat)
+	if !errors.Is(err, ErrDuplicateRequest) || rejoined.ID != player.ID {
+		t.Fatalf("retried join: got %v, want the first seat", err)
+	}
+	if len(gm.ListGames()) != 1 || len(created.Players) != 1 {
+		t.Fatalf("retries created %d games and %d players", len(gm.ListGames()), len(created.Players))
+	}
+
+	// Another request of the session takes a seat of its own
+	if _, err := gm.CreateGame(GameOptions{}, SeatRequest{SessionKey: "session", ID: "create-2"}); err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+}
+
+func TestStaleVersion(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{}, "alice", "bob")
+
+	tests := []struct {
+		name    string
+		version int
+		want    error
+	}{
+		{"rejects an old version", game.Version - 1, ErrStaleVersion},
+		{"rejects a future version", game.Version + 1, ErrStaleVersion},
+		{"accepts the current version", game.Version, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := requestFor(game, playerIDs[0])
+			request.ExpectedVersion = tt.version
+			if _, err := gm.DealCards(request); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedealMidRound(t *testing.T) {
+	ruleSets := []string{DefaultRuleSet, "crazy_eights", "hearts", "blackjack", "texas_holdem"}
+
+	for _, ruleSet := range ruleSets {
+		t.Run(ruleSet, func(t *testing.T) {
+			gm, game, playerIDs := newTestGame(t, GameOptions{RuleSet: ruleSet}, "alice", "bob", "carol", "dave")
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+				t.Fatalf("dealing: %v", err)
+			}
+			if game.Phase == PhaseFinished {
+				t.Skip("round settled as it was dealt")
+			}
+
+			hands := make([]int, len(game.Players))
+			for i, player := range game.Players {
+				hands[i] = len(player.Hand)
+			}
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); !errors.Is(err, ErrRoundInProgress) {
+				t.Fatalf("got %v, want %v", err, ErrRoundInProgress)
+			}
+			for i, player := range game.Players {
+				if len(player.Hand) != hands[i] {
+					t.Fatalf("%s holds %d cards, had %d", player.Name, len(player.Hand), hands[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDealTooManyCards(t *testing.T) {
+	gm := NewGameManager(nil)
+	_, err := gm.CreateGame(GameOptions{HandSize: 20, MaxPlayers: 4}, SeatRequest{})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidOptions)
+	}
+}
+
+func TestLeaveMidHand(t *testing.T) {
+	tests := []struct {
+		name    string
+		current bool
+	}{
+		{"player to act", true},
+		{"player waiting", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm, game, playerIDs := newTestGame(t, GameOptions{RuleSet: "texas_holdem"}, "alice", "bob", "carol")
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+				t.Fatalf("dealing: %v", err)
+			}
+
+			leaver := game.CurrentPlayer
+			if !tt.current {
+				for _, playerID := range playerIDs {
+					if playerID != game.CurrentPlayer {
+						leaver = playerID
+						break
+					}
+				}
+			}
+			if _, err := gm.LeaveGame(game.ID, leaver); err != nil {
+				t.Fatalf("leaving: %v", err)
+			}
+
+			// The others play on, checking or calling, until the hand ends
+			for moves := 0; game.Poker.Street != StreetShowdown; moves++ {
+				if moves > 50 {
+					t.Fatal("hand stalled after the player left")
+				}
+				if game.CurrentPlayer == leaver {
+					t.Fatal("turn given to the player who left")
+				}
+				request := requestFor(game, game.CurrentPlayer)
+				if _, err := gm.Bet(request, BetCheck, 0); err != nil {
+					if _, err := gm.Bet(request, BetCall, 0); err != nil {
+						t.Fatalf("calling: %v", err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPauseKeepsTurnTime(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{TurnTimer: 30}, "alice", "bob")
+	if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+	defer stopTimers(game)
+
+	current := game.CurrentPlayer
+	other := playerIDs[0]
+	if other == current {
+		other = playerIDs[1]
+	}
+
+	if _, err := gm.PauseGame(requestFor(game, other)); !errors.Is(err, ErrNotYourTurn) {
+		t.Fatalf("pause by a waiting player: got %v, want %v", err, ErrNotYourTurn)
+	}
+
+	deadline := *game.TurnDeadline
+	for i := 0; i < pausesPerPlayer; i++ {
+		if _, err := gm.PauseGame(requestFor(game, current)); err != nil {
+			t.Fatalf("pause %d: %v", i+1, err)
+		}
+		if game.TurnDeadline != nil {
+			t.Fatal("paused game kept its deadline")
+		}
+		if _, err := gm.ResumeGame(requestFor(game, current)); err != nil {
+			t.Fatalf("resume %d: %v", i+1, err)
+		}
+		if game.TurnDeadline == nil || game.TurnDeadline.After(deadline.Add(time.Second)) {
+			t.Fatalf("resume %d: deadline %v, want no later than %v", i+1, game.TurnDeadline, deadline)
+		}
+	}
+
+	if _, err := gm.PauseGame(requestFor(game, current)); !errors.Is(err, ErrActionNotAllowed) {
+		t.Fatalf("pause past the limit: got %v, want %v", err, ErrActionNotAllowed)
+	}
+}
+
+func TestResumeWaitsForDisconnectedPlayers(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{}, "alice", "bob")
+	if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+	defer stopTimers(game)
+
+	if _, err := gm.PauseGame(requestFor(game, game.CurrentPlayer)); err != nil {
+		t.Fatalf("pausing: %v", err)
+	}
+	if _, err := gm.DisconnectPlayer(game.ID, playerIDs[1]); err != nil {
+		t.Fatalf("disconnecting: %v", err)
+	}
+	if _, err := gm.ResumeGame(requestFor(game, playerIDs[0])); !errors.Is(err, ErrPlayerDisconnected) {
+		t.Fatalf("got %v, want %v", err, ErrPlayerDisconnected)
+	}
+}
+
+func TestUndoChangeThatBreaksCardCount(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{}, "alice", "bob")
+	if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+
+	version := game.Version
+	deck := len(game.Deck)
+	player := &game.Players[findPlayer(game, game.CurrentPlayer)]
+	player.Hand = append(player.Hand, player.Hand[0])
+
+	if _, err := gm.DrawCard(requestFor(game, player.ID), false); !errors.Is(err, ErrCardsMismatch) {
+		t.Fatalf("got %v, want %v", err, ErrCardsMismatch)
+	}
+	if game.Version != version || len(game.Deck) != deck {
+		t.Fatalf("change not undone: version %d, %d cards in deck", game.Version, len(game.Deck))
+	}
+	if _, err := gm.DrawCard(requestFor(game, game.CurrentPlayer), false); err != nil {
+		t.Fatalf("drawing after the undo: %v", err)
+	}
+}
+
+func TestPassingTimeout(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{RuleSet: "hearts", TurnTimer: 30}, "alice", "bob", "carol", "dave")
+	if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+	defer stopTimers(game)
+
+	if game.Phase != PhasePassing || game.TurnDeadline == nil {
+		t.Fatalf("phase %s, deadline %v: want a timed passing phase", game.Phase, game.TurnDeadline)
+	}
+
+	hand := game.Players[0].Hand
+	if _, err := gm.PassCards(requestFor(game, playerIDs[0]), []string{hand[0].ID, hand[1].ID, hand[2].ID}); err != nil {
+		t.Fatalf("passing: %v", err)
+	}
+
+	gm.expireTurn(game.ID, game.turn)
+	if game.Phase != PhasePlaying || game.CurrentPlayer == "" {
+		t.Fatalf("phase %s after the deadline, want play to start", game.Phase)
+	}
+	for _, player := range game.Players {
+		if len(player.Hand) != heartsHandSize {
+			t.Fatalf("%s holds %d cards", player.Name, len(player.Hand))
+		}
+	}
+}
+
+func TestDropCards(t *testing.T) {
+	tests := []struct {
+		name    string
+		ruleSet string
+		players int
+		waiting bool
+		all     bool
+		meldID  string
+		want    error
+	}{
+		{name: "current player drops", ruleSet: DefaultRuleSet},
+		{name: "waiting player drops", ruleSet: DefaultRuleSet, waiting: true, want: ErrNotYourTurn},
+		{name: "waiting player lays down", ruleSet: "gin_rummy", waiting: true, want: ErrNotYourTurn},
+		{name: "waiting player lays off", ruleSet: "gin_rummy", waiting: true, meldID: "meld-1", want: ErrNotYourTurn},
+		{name: "trick game", ruleSet: "hearts", players: 4, want: ErrActionNotAllowed},
+		{name: "matching game", ruleSet: "crazy_eights", want: ErrActionNotAllowed},
+		{name: "matching game whole hand out of turn", ruleSet: "crazy_eights", waiting: true, all: true, want: ErrActionNotAllowed},
+		{name: "community cards", ruleSet: "texas_holdem", want: ErrActionNotAllowed},
+		{name: "trick game out of turn", ruleSet: "hearts", players: 4, waiting: true, want: ErrActionNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{"alice", "bob", "carol", "dave"}[:max(tt.players, 2)]
+			gm, game, playerIDs := newTestGame(t, GameOptions{RuleSet: tt.ruleSet}, names...)
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+				t.Fatalf("dealing: %v", err)
+			}
+
+			// Hearts has no current player while the cards are passed
+			playerID := game.CurrentPlayer
+			if tt.waiting || playerID == "" {
+				playerID = playerIDs[0]
+				if playerID == game.CurrentPlayer {
+					playerID = playerIDs[1]
+				}
+			}
+			player := &game.Players[findPlayer(game, playerID)]
+			hand := len(player.Hand)
+
+			cardIDs := []string{player.Hand[0].ID}
+			if tt.all {
+				cardIDs = cardIDs[:0]
+				for _, card := range player.Hand {
+					cardIDs = append(cardIDs, card.ID)
+				}
+			}
+
+			_, err := gm.DropCardInSharedZone(requestFor(game, playerID), cardIDs, Position{}, tt.meldID)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+				if len(player.Hand) != hand || game.Phase == PhaseFinished {
+					t.Fatalf("rejected drop left %d cards of %d in hand and the game %s", len(player.Hand), hand, game.Phase)
+				}
+				for _, id := range playerIDs {
+					if _, err := gm.GameView(game.ID, id); err != nil {
+						t.Fatalf("viewing the game: %v", err)
+					}
+				}
+				return
+			}
+			if err != nil || len(player.Hand) != hand-1 || len(game.SharedZone) != 1 {
+				t.Fatalf("got %v with %d cards in hand and %d shared", err, len(player.Hand), len(game.SharedZone))
+			}
+		})
+	}
+}
+
+func TestPokerNewGame(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{RuleSet: "texas_holdem"}, "alice", "bob")
+
+	// Both players go all in every hand until one of them has every chip
+	for hand := 0; game.Phase != PhaseFinished; hand++ {
+		if hand > 50 {
+			t.Fatal("game never finished")
+		}
+		if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+			t.Fatalf("hand %d: dealing: %v", hand+1, err)
+		}
+		for game.Poker.Street != StreetShowdown {
+			if _, err := gm.Bet(requestFor(game, game.CurrentPlayer), BetAllIn, 0); err != nil {
+				if _, err := gm.Bet(requestFor(game, game.CurrentPlayer), BetCall, 0); err != nil {
+					t.Fatalf("hand %d: calling: %v", hand+1, err)
+				}
+			}
+		}
+	}
+
+	if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+		t.Fatalf("dealing a new game: %v", err)
+	}
+	if game.Phase != PhasePlaying || game.Poker.HandNumber != 1 {
+		t.Fatalf("new game in phase %s at hand %d", game.Phase, game.Poker.HandNumber)
+	}
+	for _, player := range game.Players {
+		seat := game.Poker.Seats[player.ID]
+		if seat == nil || player.Chips+seat.Committed != pokerStartingChips {
+			t.Fatalf("%s did not buy in again: %d chips", player.Name, player.Chips)
+		}
+	}
+}
+
+func TestRestoreGames(t *testing.T) {
+	cards := createDeck()
+	store := NewMemoryStore()
+	for _, game := range []*Game{
+		{ID: "no-options", Phase: PhaseWaiting},
+		{ID: "too-many-players", Phase: PhaseWaiting, Options: GameOptions{MaxPlayers: 50}},
+		{ID: "copied-card", Phase: PhaseWaiting, Deck: []Card{cards[0], cards[0]}},
+	} {
+		if err := store.Save(game); err != nil {
+			t.Fatalf("saving %s: %v", game.ID, err)
+		}
+	}
+
+	gm := NewGameManager(store)
+	err := gm.RestoreGames()
+	if !errors.Is(err, ErrInvalidOptions) || !errors.Is(err, ErrCardsMismatch) {
+		t.Fatalf("got %v, want %v and %v", err, ErrInvalidOptions, ErrCardsMismatch)
+	}
+	gm.FlushStore()
+
+	game, err := gm.GetGame("no-options")
+	if err != nil {
+		t.Fatalf("getting the restored game: %v", err)
+	}
+	if game.Options.MaxPlayers != (FreePlay{}).MaxPlayers() || game.Options.ReconnectGrace != defaultReconnectGrace {
+		t.Fatalf("restored with options %+v", game.Options)
+	}
+	if _, _, err := gm.JoinGame(game.ID, "alice", "", "", SeatRequest{}); err != nil {
+		t.Fatalf("joining the restored game: %v", err)
+	}
+
+	for _, gameID := range []string{"too-many-players", "copied-card"} {
+		if _, err := gm.GetGame(gameID); !errors.Is(err, ErrGameNotFound) {
+			t.Fatalf("%s: got %v, want %v", gameID, err, ErrGameNotFound)
+		}
+	}
+	stored, err := store.LoadLive()
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("store holds %d games: %v", len(stored), err)
+	}
+}
+
+func TestHostGame(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    GameOptions
+		playerName string
+		want       error
+		wantField  string
+	}{
+		{name: "seats the host", playerName: "alice"},
+		{name: "rejects a long name", playerName: strings.Repeat("x", maxPlayerNameLength+1), wantField: "playerName"},
+		{name: "rejects invalid options", options: GameOptions{MaxPlayers: 50}, playerName: "alice", want: ErrInvalidOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			gm := NewGameManager(store)
+
+			game, player, err := gm.HostGame(tt.options, tt.playerName, "", SeatRequest{})
+			gm.FlushStore()
+			stored, loadErr := store.LoadLive()
+			if loadErr != nil {
+				t.Fatalf("loading: %v", loadErr)
+			}
+
+			var payloadErr *PayloadError
+			switch {
+			case tt.wantField != "":
+				if !errors.As(err, &payloadErr) || payloadErr.Field != tt.wantField {
+					t.Fatalf("got %v, want a payload error on %s", err, tt.wantField)
+				}
+			case tt.want != nil:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+			case err != nil:
+				t.Fatalf("got %v", err)
+			default:
+				if len(game.Players) != 1 || game.Players[0].ID != player.ID || len(stored) != 1 {
+					t.Fatalf("host not seated: %d players, %d games stored", len(game.Players), len(stored))
+				}
+				return
+			}
+
+			// A rejected host leaves no game behind
+			if len(gm.ListGames()) != 0 || len(stored) != 0 {
+				t.Fatalf("%d games kept and %d stored", len(gm.ListGames()), len(stored))
+			}
+		})
+	}
+}
+
+func TestDealAfterLeaveMidRound(t *testing.T) {
+	ruleSets := []string{DefaultRuleSet, "crazy_eights", "hearts", "brisca", "tute", "truco", "gin_rummy"}
+
+	for _, ruleSet := range ruleSets {
+		t.Run(ruleSet, func(t *testing.T) {
+			rules, err := GetRuleSet(ruleSet)
+			if err != nil {
+				t.Fatalf("getting the ruleset: %v", err)
+			}
+			names := []string{"alice", "bob", "carol", "dave"}[:rules.MinPlayers()]
+			gm, game, playerIDs := newTestGame(t, GameOptions{RuleSet: ruleSet}, names...)
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+				t.Fatalf("dealing: %v", err)
+			}
+
+			if _, err := gm.LeaveGame(game.ID, playerIDs[len(playerIDs)-1]); err != nil {
+				t.Fatalf("leaving: %v", err)
+			}
+			// Truco gives the game to the side left at the table
+			if game.Phase != PhaseWaiting && game.Phase != PhaseFinished {
+				t.Fatalf("round still in play after the leave, phase %s", game.Phase)
+			}
+			if _, _, err := gm.JoinGame(game.ID, "erin", "", "", SeatRequest{}); err != nil {
+				t.Fatalf("joining: %v", err)
+			}
+
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+				t.Fatalf("dealing again: %v", err)
+			}
+			for _, player := range game.Players {
+				if len(player.Hand) == 0 {
+					t.Fatalf("%s was dealt no cards", player.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestTimedChangeThatBreaksCardCount(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    GameOptions
+		disconnect bool
+		// change makes the change, returning the error it reports
+		change func(gm *GameManager, game *Game, leaver string) error
+	}{
+		{
+			name:    "turn timer",
+			options: GameOptions{TurnTimer: 30},
+			change: func(gm *GameManager, game *Game, leaver string) error {
+				gm.expireTurn(game.ID, game.turn)
+				return nil
+			},
+		},
+		{
+			name:    "time bank",
+			options: GameOptions{TimeBank: 60},
+			change: func(gm *GameManager, game *Game, leaver string) error {
+				gm.expireClock(game.ID, game.clockStarted)
+				return nil
+			},
+		},
+		{
+			name:       "grace period",
+			disconnect: true,
+			change: func(gm *GameManager, game *Game, leaver string) error {
+				player := game.Players[findPlayer(game, leaver)]
+				gm.expireGrace(game.ID, leaver, player.DisconnectedAt)
+				return nil
+			},
+		},
+		{
+			name: "leave",
+			change: func(gm *GameManager, game *Game, leaver string) error {
+				_, err := gm.LeaveGame(game.ID, leaver)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm, game, playerIDs := newTestGame(t, tt.options, "alice", "bob", "carol")
+			if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+				t.Fatalf("dealing: %v", err)
+			}
+			defer stopTimers(game)
+
+			leaver := playerIDs[2]
+			if tt.disconnect {
+				if _, err := gm.DisconnectPlayer(game.ID, leaver); err != nil {
+					t.Fatalf("disconnecting: %v", err)
+				}
+			}
+			gm.FlushStore()
+
+			player := &game.Players[findPlayer(game, game.CurrentPlayer)]
+			player.Hand = append(player.Hand, player.Hand[0])
+
+			err := tt.change(gm, game, leaver)
+			if err == nil {
+				err = gm.StoreError()
+			}
+			if !errors.Is(err, ErrCardsMismatch) {
+				t.Fatalf("got %v, want %v", err, ErrCardsMismatch)
+			}
+			if findPlayer(game, leaver) == -1 {
+				t.Fatal("failed change not undone")
+			}
+		})
+	}
+}
+
+func TestTimeForfeitEndsMatch(t *testing.T) {
+	gm, game, playerIDs := newTestGame(t, GameOptions{TimeBank: 60, Rounds: 3}, "alice", "bob")
+	if _, err := gm.DealCards(requestFor(game, playerIDs[0])); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+	defer stopTimers(game)
+
+	// The player out of time leads the match, but cannot win it
+	forfeited := game.clockPlayer
+	game.Players[findPlayer(game, forfeited)].Score = 50
+	gm.TakeEvents(game.ID)
+
+	gm.expireClock(game.ID, game.clockStarted)
+	if game.Phase != PhaseFinished || len(game.Match.Rounds) != 1 {
+		t.Fatalf("phase %s with %d rounds scored", game.Phase, len(game.Match.Rounds))
+	}
+
+	var ended *MatchEndedData
+	for _, event := range gm.TakeEvents(game.ID) {
+		if data, ok := event.Data.(MatchEndedData); ok {
+			ended = &data
+		}
+	}
+	if ended == nil || len(ended.Winners) != 1 || ended.Winners[0] == forfeited {
+		t.Fatalf("match ended with %+v, want the other player to win", ended)
+	}
+}
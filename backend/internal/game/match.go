@@ -0,0 +1,219 @@
+package game
+
+// MatchState tracks the rounds of a match. A match is played by rulesets
+// that implement RoundScorer and ends at the table's target score or
+// number of rounds, or after a single round when neither is set.
+type MatchState struct {
+	Round  int           `json:"round"`
+	Rounds []RoundResult `json:"rounds"`
+}
+
+// RoundResult is the line of the score sheet for one round
+type RoundResult struct {
+	Round  int            `json:"round"`
+	Points map[string]int `json:"points"`
+}
+
+// ScoreSheet is the points of every round played so far and the players'
+// running totals
+type ScoreSheet struct {
+	Rounds []RoundResult  `json:"rounds"`
+	Totals map[string]int `json:"totals"`
+}
+
+type RoundStartedData struct {
+	Round int `json:"round"`
+}
+
+type RoundScoredData struct {
+	Round  int            `json:"round"`
+	Points map[string]int `json:"points"`
+	Sheet  ScoreSheet     `json:"sheet"`
+}
+
+type MatchEndedData struct {
+	Winners []string   `json:"winners"`
+	Sheet   ScoreSheet `json:"sheet"`
+}
+
+// startMatch clears the scores and deals the first round
+func startMatch(game *Game, rules RuleSet) error {
+	game.Match = &MatchState{Rounds: make([]RoundResult, 0)}
+	for i := range game.Players {
+		game.Players[i].Score = 0
+	}
+	return startRound(game, rules)
+}
+
+// startRound gathers every card back into the deck and deals the next
+// round
+func startRound(game *Game, rules RuleSet) error {
+	gatherCards(game)
+	if err := rules.Deal(game); err != nil {
+		return err
+	}
+
+	game.Match.Round++
+	game.emit(MsgRoundStarted, RoundStartedData{Round: game.Match.Round})
+	return nil
+}
+
+// finishIfOver checks the ruleset's end condition after a move. Rounds of a
+// match are scored and the next one dealt until the match is over; other
+// games simply finish.
+func finishIfOver(game *Game, rules RuleSet) {
+	if !rules.IsOver(game) {
+		return
+	}
+
+	scorer, ok := rules.(RoundScorer)
+	if !ok || game.Match == nil {
+		game.Phase = PhaseFinished
+		rules.Score(game)
+		return
+	}
+
+	scoreRound(game, scorer)
+	if !matchOver(game) && startRound(game, rules) == nil {
+		return
+	}
+
+	game.Phase = PhaseFinished
+	game.emit(MsgMatchEnded, MatchEndedData{Winners: matchWinners(game, ""), Sheet: scoreSheet(game)})
+}
+
+// forfeitGame ends the game of a player who ran out of time, for rulesets
+// that do not decide it themselves. The game is scored as it stands, as a
+// finished game would be, and the player cannot win the match.
+func forfeitGame(game *Game, rules RuleSet, player *Player) {
+	game.Phase = PhaseFinished
+
+	scorer, ok := rules.(RoundScorer)
+	if !ok || game.Match == nil {
+		rules.Score(game)
+		return
+	}
+
+	scoreRound(game, scorer)
+	game.emit(MsgMatchEnded, MatchEndedData{Winners: matchWinners(game, player.ID), Sheet: scoreSheet(game)})
+}
+
+// scoreRound adds the points of the round in play to the players' scores
+// and to the match
+func scoreRound(game *Game, scorer RoundScorer) {
+	points := scorer.ScoreRound(game)
+	for i := range game.Players {
+		game.Players[i].Score += points[game.Players[i].ID]
+	}
+
+	game.Match.Rounds = append(game.Match.Rounds, RoundResult{Round: game.Match.Round, Points: points})
+	game.emit(MsgRoundScored, RoundScoredData{Round: game.Match.Round, Points: points, Sheet: scoreSheet(game)})
+}
+
+// matchOver reports whether a player has reached the target score or the
+// last round has been played
+func matchOver(game *Game) bool {
+	target, rounds := game.Options.TargetScore, game.Options.Rounds
+	if target == 0 && rounds == 0 {
+		return true
+	}
+
+	if rounds > 0 && game.Match.Round >= rounds {
+		return true
+	}
+
+	if target > 0 {
+		for _, player := range game.Players {
+			if player.Score >= target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchWinners lists the players with the highest total, leaving out a
+// player who forfeited
+func matchWinners(game *Game, forfeited string) []string {
+	best, found := 0, false
+	for _, player := range game.Players {
+		if player.ID != forfeited && (!found || player.Score > best) {
+			best, found = player.Score, true
+		}
+	}
+
+	winners := make([]string, 0, 1)
+	for _, player := range game.Players {
+		if player.ID != forfeited && player.Score == best {
+			winners = append(winners, player.ID)
+		}
+	}
+	return winners
+}
+
+// scoreSheet copies the rounds played and the players' totals
+func scoreSheet(game *Game) ScoreSheet {
+	sheet := ScoreSheet{Rounds: make([]RoundResult, 0), Totals: make(map[string]int)}
+	if game.Match != nil {
+		sheet.Rounds = append(sheet.Rounds, game.Match.Rounds...)
+	}
+
+	for _, player := range game.Players {
+		sheet.Totals[player.ID] = player.Score
+	}
+	return sheet
+}
+
+// goingOutPoints scores a round won by emptying a hand: the player who went
+// out earns the points of every card left in the other players' hands
+func goingOutPoints(game *Game, cardPoints func(Card) int) map[string]int {
+	points := make(map[string]int)
+	winner := ""
+	total := 0
+	for _, player := range game.Players {
+		if len(player.Hand) == 0 {
+			winner = player.ID
+		}
+		for _, card := range player.Hand {
+			total += cardPoints(card)
+		}
+	}
+
+	if winner != "" {
+		points[winner] = total
+	}
+	return points
+}
+
+// gatherCards moves the hands, the table and the discard pile back into the
+// deck, ready for a new deal
+func gatherCards(game *Game) {
+	for i := range game.Players {
+		game.Deck = append(game.Deck, game.Players[i].Hand...)
+		game.Players[i].Hand = make([]Card, 0)
+	}
+
+	for _, meld := range game.Melds {
+		game.Deck = append(game.Deck, meld.Cards...)
+	}
+
+	game.Deck = append(game.Deck, game.SharedZone...)
+	game.Deck = append(game.Deck, game.PlayedCards...)
+	game.Melds = nil
+	game.SharedZone = make([]Card, 0)
+	game.PlayedCards = make([]Card, 0)
+}
+
+// abandonRound ends the round in play when too few players are left to
+// finish it. The cards go back into the deck and the round state of the
+// rulesets is dropped, so the next deal starts a new game. Chips are kept.
+func abandonRound(game *Game) {
+	gatherCards(game)
+	game.Match = nil
+	game.CrazyEights = nil
+	game.Hearts = nil
+	game.Brisca = nil
+	game.Tute = nil
+	game.Truco = nil
+	game.GinRummy = nil
+}
@@ -0,0 +1,315 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// MeldKind is the shape of a meld
+type MeldKind string
+
+const (
+	MeldSet MeldKind = "set"
+	MeldRun MeldKind = "run"
+)
+
+// runLength is the number of ranks a run can span
+const runLength = 13
+
+// Meld is a set or run of cards laid down in the shared area
+type Meld struct {
+	ID      string   `json:"id"`
+	Kind    MeldKind `json:"kind"`
+	OwnerID string   `json:"ownerId"`
+	Cards   []Card   `json:"cards"`
+}
+
+// MeldRules configures the meld engine for a ruleset. Sets are cards of
+// one rank in different suits and runs are cards of one suit in sequence,
+// ordered by card value.
+type MeldRules struct {
+	MinSize int
+	// AceHigh lets an ace follow the king in runs as well as lead the two
+	AceHigh bool
+	// IsWild reports whether a card can stand in for any other. A nil
+	// IsWild means the game has no wild cards.
+	IsWild func(Card) bool
+}
+
+// NewMeld validates the cards as a meld and lays them down for a player
+func (r MeldRules) NewMeld(ownerID string, cards []Card) (Meld, error) {
+	kind, err := r.Validate(cards)
+	if err != nil {
+		return Meld{}, err
+	}
+
+	return Meld{
+		ID:      uuid.New().String(),
+		Kind:    kind,
+		OwnerID: ownerID,
+		Cards:   r.arrange(kind, cards),
+	}, nil
+}
+
+// Validate reports whether the cards form a set or a run
+func (r MeldRules) Validate(cards []Card) (MeldKind, error) {
+	if len(cards) < r.MinSize {
+		return "", errorOf(ErrInvalidMeld, fmt.Sprintf("a meld needs at least %d cards", r.MinSize))
+	}
+
+	naturals, wilds := r.split(cards)
+	if len(naturals) == 0 {
+		return "", errorOf(ErrInvalidMeld, "a meld needs at least one natural card")
+	}
+
+	if r.isSet(naturals) {
+		return MeldSet, nil
+	}
+	if r.isRun(naturals, wilds) {
+		return MeldRun, nil
+	}
+	return "", errorOf(ErrInvalidMeld, "cards do not form a set or a run")
+}
+
+// Extend validates cards laid off onto a meld and returns the meld with
+// the cards added
+func (r MeldRules) Extend(meld Meld, cards []Card) (Meld, error) {
+	combined := append(append([]Card{}, meld.Cards...), cards...)
+	kind, err := r.Validate(combined)
+	if err != nil || kind != meld.Kind {
+		return Meld{}, errorOf(ErrInvalidMeld, "cards do not fit the meld")
+	}
+
+	meld.Cards = r.arrange(kind, combined)
+	return meld, nil
+}
+
+// BestMelds arranges a hand into the melds that leave the least deadwood,
+// as counted by points. Wild cards are left out of the search and count as
+// deadwood.
+func (r MeldRules) BestMelds(hand []Card, points func(Card) int) ([][]Card, []Card) {
+	naturals, _ := r.split(hand)
+	candidates := r.candidates(naturals)
+
+	bestDeadwood := -1
+	var bestMelds [][]Card
+	used := make(map[string]bool)
+	chosen := make([][]Card, 0)
+
+	var search func(start int)
+	search = func(start int) {
+		deadwood := 0
+		for _, card := range hand {
+			if !used[card.ID] {
+				deadwood += points(card)
+			}
+		}
+		if bestDeadwood == -1 || deadwood < bestDeadwood {
+			bestDeadwood = deadwood
+			bestMelds = append([][]Card{}, chosen...)
+		}
+
+		for i := start; i < len(candidates); i++ {
+			free := true
+			for _, card := range candidates[i] {
+				if used[card.ID] {
+					free = false
+					break
+				}
+			}
+			if !free {
+				continue
+			}
+
+			for _, card := range candidates[i] {
+				used[card.ID] = true
+			}
+			chosen = append(chosen, candidates[i])
+			search(i + 1)
+			chosen = chosen[:len(chosen)-1]
+			for _, card := range candidates[i] {
+				used[card.ID] = false
+			}
+		}
+	}
+	search(0)
+
+	melded := make(map[string]bool)
+	for _, meld := range bestMelds {
+		for _, card := range meld {
+			melded[card.ID] = true
+		}
+	}
+
+	deadwood := make([]Card, 0)
+	for _, card := range hand {
+		if !melded[card.ID] {
+			deadwood = append(deadwood, card)
+		}
+	}
+	return bestMelds, deadwood
+}
+
+// candidates lists every set and run that can be made from the cards
+func (r MeldRules) candidates(cards []Card) [][]Card {
+	candidates := make([][]Card, 0)
+
+	byRank := make(map[string][]Card)
+	bySuit := make(map[Suit][]Card)
+	for _, card := range cards {
+		byRank[card.Rank] = append(byRank[card.Rank], card)
+		bySuit[card.Suit] = append(bySuit[card.Suit], card)
+	}
+
+	// Every combination of a rank large enough to be a set
+	for _, group := range byRank {
+		for mask := 1; mask < 1<<len(group); mask++ {
+			subset := make([]Card, 0, len(group))
+			for i, card := range group {
+				if mask&(1<<i) != 0 {
+					subset = append(subset, card)
+				}
+			}
+			if len(subset) >= r.MinSize && r.isSet(subset) {
+				candidates = append(candidates, subset)
+			}
+		}
+	}
+
+	// Every stretch of consecutive cards of a suit
+	for _, group := range bySuit {
+		for _, aceHigh := range r.aceOptions() {
+			sorted := r.sortRun(group, aceHigh)
+			for start := range sorted {
+				for end := start + r.MinSize; end <= len(sorted); end++ {
+					if r.runFits(sorted[start:end], 0, aceHigh) {
+						candidates = append(candidates, append([]Card{}, sorted[start:end]...))
+					}
+				}
+			}
+		}
+	}
+
+	return candidates
+}
+
+// split separates natural cards from wild cards
+func (r MeldRules) split(cards []Card) ([]Card, int) {
+	naturals := make([]Card, 0, len(cards))
+	wilds := 0
+	for _, card := range cards {
+		if r.IsWild != nil && r.IsWild(card) {
+			wilds++
+			continue
+		}
+		naturals = append(naturals, card)
+	}
+	return naturals, wilds
+}
+
+// isSet reports whether natural cards share a rank with no suit repeated
+func (r MeldRules) isSet(naturals []Card) bool {
+	suits := make(map[Suit]bool)
+	for _, card := range naturals {
+		if card.Rank != naturals[0].Rank || suits[card.Suit] {
+			return false
+		}
+		suits[card.Suit] = true
+	}
+	return true
+}
+
+// isRun reports whether natural cards of one suit, with the wild cards
+// filling the gaps, make a sequence
+func (r MeldRules) isRun(naturals []Card, wilds int) bool {
+	for _, aceHigh := range r.aceOptions() {
+		if r.runFits(naturals, wilds, aceHigh) {
+			return true
+		}
+	}
+	return false
+}
+
+// runFits reports whether the cards make a run with the ace counted low or
+// high
+func (r MeldRules) runFits(naturals []Card, wilds int, aceHigh bool) bool {
+	sorted := r.sortRun(naturals, aceHigh)
+	gaps := 0
+	for i, card := range sorted {
+		if card.Suit != sorted[0].Suit {
+			return false
+		}
+		if i == 0 {
+			continue
+		}
+		step := r.runValue(card, aceHigh) - r.runValue(sorted[i-1], aceHigh)
+		if step == 0 {
+			return false
+		}
+		gaps += step - 1
+	}
+
+	// Wild cards left over extend the run at either end
+	return gaps <= wilds && len(naturals)+wilds <= runLength
+}
+
+// arrange orders the cards of a run by value with the wild cards at the end
+func (r MeldRules) arrange(kind MeldKind, cards []Card) []Card {
+	if kind != MeldRun {
+		return cards
+	}
+
+	naturals, wilds := r.split(cards)
+	arranged := naturals
+	for _, aceHigh := range r.aceOptions() {
+		if r.runFits(naturals, wilds, aceHigh) {
+			arranged = r.sortRun(naturals, aceHigh)
+			break
+		}
+	}
+
+	for _, card := range cards {
+		if r.IsWild != nil && r.IsWild(card) {
+			arranged = append(arranged, card)
+		}
+	}
+	return arranged
+}
+
+// aceOptions lists the ways the ace can be counted in runs
+func (r MeldRules) aceOptions() []bool {
+	if r.AceHigh {
+		return []bool{false, true}
+	}
+	return []bool{false}
+}
+
+// sortRun returns the cards sorted by their value in a run
+func (r MeldRules) sortRun(cards []Card, aceHigh bool) []Card {
+	sorted := append([]Card{}, cards...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return r.runValue(sorted[i], aceHigh) < r.runValue(sorted[j], aceHigh)
+	})
+	return sorted
+}
+
+// runValue is the position of a card in a run, with a high ace after the
+// king
+func (r MeldRules) runValue(card Card, aceHigh bool) int {
+	if aceHigh && card.Value == 1 {
+		return runLength + 1
+	}
+	return card.Value
+}
+
+// findMeld returns the index of a meld in the shared area, or -1
+func findMeld(game *Game, meldID string) int {
+	for i, meld := range game.Melds {
+		if meld.ID == meldID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMeldValidate(t *testing.T) {
+	wild := MeldRules{MinSize: 3, IsWild: IsJoker}
+	aceHigh := MeldRules{MinSize: 3, AceHigh: true}
+
+	tests := []struct {
+		name  string
+		rules MeldRules
+		cards string
+		want  MeldKind
+	}{
+		{"set of three", ginMelds, "7h 7d 7c", MeldSet},
+		{"set of four", ginMelds, "Kh Kd Kc Ks", MeldSet},
+		{"run of three", ginMelds, "4s 5s 6s", MeldRun},
+		{"run out of order", ginMelds, "9d Jd 10d Qd", MeldRun},
+		{"ace low run", ginMelds, "Ah 2h 3h", MeldRun},
+		{"ace high run", aceHigh, "Qh Kh Ah", MeldRun},
+		{"wild fills a gap", wild, "4s * 6s", MeldRun},
+		{"wild joins a set", wild, "9h 9c *", MeldSet},
+		{"too few cards", ginMelds, "7h 7d", ""},
+		{"set with a repeated suit", ginMelds, "7h 7h 7c", ""},
+		{"run of mixed suits", ginMelds, "4s 5h 6s", ""},
+		{"run with a gap", ginMelds, "4s 5s 7s", ""},
+		{"ace high without the option", ginMelds, "Qh Kh Ah", ""},
+		{"only wild cards", wild, "* * *", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, err := tt.rules.Validate(testCards(tt.cards))
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidMeld) {
+					t.Fatalf("got %s, %v, want %v", kind, err, ErrInvalidMeld)
+				}
+				return
+			}
+			if err != nil || kind != tt.want {
+				t.Fatalf("got %s, %v, want %s", kind, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeldExtend(t *testing.T) {
+	tests := []struct {
+		name    string
+		meld    string
+		cards   string
+		wantErr bool
+	}{
+		{"run extended at the top", "4s 5s 6s", "7s", false},
+		{"run extended at the bottom", "4s 5s 6s", "3s", false},
+		{"set given its fourth suit", "7h 7d 7c", "7s", false},
+		{"run given another suit", "4s 5s 6s", "7h", true},
+		{"set given another rank", "7h 7d 7c", "8s", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meld, err := ginMelds.NewMeld("owner", testCards(tt.meld))
+			if err != nil {
+				t.Fatalf("laying down: %v", err)
+			}
+
+			extended, err := ginMelds.Extend(meld, testCards(tt.cards))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMeld) {
+					t.Fatalf("got %v, want %v", err, ErrInvalidMeld)
+				}
+				return
+			}
+			if err != nil || len(extended.Cards) != len(meld.Cards)+1 {
+				t.Fatalf("got %v with %d cards", err, len(extended.Cards))
+			}
+		})
+	}
+}
+
+func TestBestMelds(t *testing.T) {
+	points := func(card Card) int { return min(card.Value, 10) }
+
+	tests := []struct {
+		name     string
+		hand     string
+		melds    int
+		deadwood int
+	}{
+		{"no melds", "Kh Qd 9c 7s 5h 3d Ac 2s 4h 6d", 0, 57},
+		{"gin", "7h 7d 7c 4s 5s 6s Kh Kd Kc Ks", 3, 0},
+		{"set leaving less than the run", "5h 6h 7h 7d 7c 2s 9d Jc Kh Qs", 1, 5 + 6 + 2 + 9 + 10 + 10 + 10},
+		{"long run split for a set", "6h 7h 8h 9h 9d 9c Ks Qs 2d Ac", 2, 10 + 10 + 2 + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hand := testCards(tt.hand)
+			melds, deadwood := ginMelds.BestMelds(hand, points)
+
+			total := 0
+			for _, card := range deadwood {
+				total += points(card)
+			}
+			if len(melds) != tt.melds || total != tt.deadwood {
+				t.Fatalf("got %d melds and %d deadwood, want %d and %d", len(melds), total, tt.melds, tt.deadwood)
+			}
+
+			melded := 0
+			for _, meld := range melds {
+				if _, err := ginMelds.Validate(meld); err != nil {
+					t.Fatalf("invalid meld %v: %v", meld, err)
+				}
+				melded += len(meld)
+			}
+			if melded+len(deadwood) != len(hand) {
+				t.Fatalf("%d cards melded and %d left of %d", melded, len(deadwood), len(hand))
+			}
+		})
+	}
+}
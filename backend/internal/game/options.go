@@ -0,0 +1,101 @@
+package game
+
+import "fmt"
+
+const maxDecks = 8
+
+// GameOptions is the table configuration chosen when a game is created.
+// Zero values fall back to the ruleset's defaults.
+type GameOptions struct {
+	RuleSet    string `json:"ruleSet"`
+	MinPlayers int    `json:"minPlayers"`
+	MaxPlayers int    `json:"maxPlayers"`
+	// HandSize and Decks are used by rulesets that do not fix the hand
+	// size or the number of decks themselves, and Jokers adds two jokers
+	// to each deck of free play games
+	HandSize int  `json:"handSize"`
+	Decks    int  `json:"decks"`
+	Jokers   bool `json:"jokers"`
+	// TargetScore and Rounds end a match of a ruleset played in rounds,
+	// after a single round when both are 0
+	TargetScore int `json:"targetScore"`
+	Rounds      int `json:"rounds"`
+	// TurnTimer is the number of seconds a player has to act, 0 for none.
+	// TimeBank is each player's total thinking time in seconds, with
+	// TimeIncrement seconds added after every turn.
+	TurnTimer     int `json:"turnTimer"`
+	TimeBank      int `json:"timeBank"`
+	TimeIncrement int `json:"timeIncrement"`
+	// ReconnectGrace is how many seconds a disconnected player's seat is
+	// held, 30 by default
+	ReconnectGrace int  `json:"reconnectGrace"`
+	Private        bool `json:"private"`
+	ProvablyFair   bool `json:"provablyFair"`
+}
+
+// tableDeck is the deck a game is created with, made of the number of
+// decks and jokers asked for
+func tableDeck(options GameOptions) DeckBuilder {
+	builder := DeckBuilder{Spec: FrenchDeck, Decks: options.Decks}
+	if options.Jokers {
+		builder.Jokers = jokersPerDeck
+	}
+	return builder
+}
+
+// resolveOptions checks the options against the ruleset and fills in its
+// defaults
+func resolveOptions(rules RuleSet, options GameOptions) (GameOptions, error) {
+	options.RuleSet = rules.Name()
+
+	if options.MinPlayers == 0 {
+		options.MinPlayers = rules.MinPlayers()
+	}
+	if options.MaxPlayers == 0 {
+		options.MaxPlayers = rules.MaxPlayers()
+	}
+
+	if options.MinPlayers < rules.MinPlayers() || options.MaxPlayers > rules.MaxPlayers() {
+		return options, errorOf(ErrInvalidOptions, fmt.Sprintf("%s is played by %d to %d players", rules.Name(), rules.MinPlayers(), rules.MaxPlayers()))
+	}
+
+	if options.MinPlayers > options.MaxPlayers {
+		return options, errorOf(ErrInvalidOptions, "minimum players cannot exceed maximum players")
+	}
+
+	if options.HandSize < 0 {
+		return options, errorOf(ErrInvalidOptions, "hand size cannot be negative")
+	}
+
+	if options.Decks < 0 || options.Decks > maxDecks {
+		return options, errorOf(ErrInvalidOptions, fmt.Sprintf("number of decks must be between 1 and %d", maxDecks))
+	}
+
+	// Every seat must be dealt a full hand from the table's cards
+	if size := tableDeck(options).Size(); options.HandSize*options.MaxPlayers > size {
+		return options, errorOf(ErrInvalidOptions, fmt.Sprintf("%d cards cannot be dealt to each of %d players from %d cards", options.HandSize, options.MaxPlayers, size))
+	}
+
+	if options.TargetScore < 0 || options.Rounds < 0 {
+		return options, errorOf(ErrInvalidOptions, "target score and rounds cannot be negative")
+	}
+
+	if options.TurnTimer < 0 || options.TimeBank < 0 || options.TimeIncrement < 0 {
+		return options, errorOf(ErrInvalidOptions, "turn timer and time bank cannot be negative")
+	}
+
+	if options.ReconnectGrace == 0 {
+		options.ReconnectGrace = defaultReconnectGrace
+	}
+	if options.ReconnectGrace < 0 {
+		return options, errorOf(ErrInvalidOptions, "reconnect grace period cannot be negative")
+	}
+
+	if checker, ok := rules.(OptionsChecker); ok {
+		if err := checker.CheckOptions(options); err != nil {
+			return options, err
+		}
+	}
+
+	return options, nil
+}
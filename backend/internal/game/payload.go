@@ -0,0 +1,178 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+)
+
+// The longest game IDs and player names the store can hold
+const (
+	maxGameIDLength     = 36
+	maxPlayerNameLength = 100
+)
+
+// PayloadError reports a message whose data is missing or malformed
+type PayloadError struct {
+	Field   string
+	Message string
+}
+
+func (e *PayloadError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Validator is implemented by payloads that check their own fields once
+// decoded
+type Validator interface {
+	Validate() error
+}
+
+// DecodePayload reads the data of a message into its payload type and
+// validates it. A message sent without data decodes to the zero payload.
+func DecodePayload(data json.RawMessage, payload interface{}) error {
+	if len(data) > 0 && string(data) != "null" {
+		if err := json.Unmarshal(data, payload); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return &PayloadError{Field: typeErr.Field, Message: "expected " + jsonKind(typeErr.Type)}
+			}
+			return &PayloadError{Message: "invalid data"}
+		}
+	}
+
+	if validator, ok := payload.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// jsonKind names the kind of JSON value a field is decoded from
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return t.String()
+}
+
+// checkLength checks that a field fits the store
+func checkLength(field, value string, limit int) error {
+	if utf8.RuneCountInString(value) > limit {
+		return &PayloadError{Field: field, Message: fmt.Sprintf("must be at most %d characters", limit)}
+	}
+	return nil
+}
+
+// requireCardID checks that a card is named
+func requireCardID(field, cardID string) error {
+	if cardID == "" {
+		return &PayloadError{Field: field, Message: "is required"}
+	}
+	return nil
+}
+
+// requireCardIDs checks a list of cards is not empty and names every card
+func requireCardIDs(field string, cardIDs []string) error {
+	if len(cardIDs) == 0 {
+		return &PayloadError{Field: field, Message: "is required"}
+	}
+	for i, cardID := range cardIDs {
+		if err := requireCardID(fmt.Sprintf("%s[%d]", field, i), cardID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *CreateGameData) Validate() error {
+	if d.PlayerName == "" {
+		return &PayloadError{Field: "playerName", Message: "is required"}
+	}
+	return checkLength("playerName", d.PlayerName, maxPlayerNameLength)
+}
+
+func (d *JoinGameData) Validate() error {
+	if d.PlayerName == "" {
+		return &PayloadError{Field: "playerName", Message: "is required"}
+	}
+	if err := checkLength("playerName", d.PlayerName, maxPlayerNameLength); err != nil {
+		return err
+	}
+	return checkLength("gameId", d.GameID, maxGameIDLength)
+}
+
+func (d *ResumeSessionData) Validate() error {
+	if d.ResumeToken == "" {
+		return &PayloadError{Field: "resumeToken", Message: "is required"}
+	}
+	return nil
+}
+
+func (d *PlayCardData) Validate() error {
+	return requireCardID("cardId", d.CardID)
+}
+
+func (d *DropCardsData) Validate() error {
+	if len(d.CardIDs) > 0 {
+		return requireCardIDs("cardIds", d.CardIDs)
+	}
+	return requireCardID("cardId", d.CardID)
+}
+
+// Cards returns the IDs of the cards dropped
+func (d *DropCardsData) Cards() []string {
+	if len(d.CardIDs) > 0 {
+		return d.CardIDs
+	}
+	return []string{d.CardID}
+}
+
+func (d *DrawCardData) Validate() error {
+	if d.Source != "" && d.Source != "deck" && d.Source != "discard" {
+		return &PayloadError{Field: "source", Message: "must be deck or discard"}
+	}
+	return nil
+}
+
+func (d *DiscardCardData) Validate() error {
+	return requireCardID("cardId", d.CardID)
+}
+
+func (d *KnockData) Validate() error {
+	return requireCardID("cardId", d.CardID)
+}
+
+func (d *PassCardsData) Validate() error {
+	return requireCardIDs("cardIds", d.CardIDs)
+}
+
+func (d *RaiseData) Validate() error {
+	if d.Amount <= 0 {
+		return &PayloadError{Field: "amount", Message: "must be positive"}
+	}
+	return nil
+}
+
+func (d *PlaceBetData) Validate() error {
+	if d.Amount <= 0 {
+		return &PayloadError{Field: "amount", Message: "must be positive"}
+	}
+	return nil
+}
@@ -0,0 +1,644 @@
+package game
+
+import "sort"
+
+// BetAction is a betting move in poker
+type BetAction string
+
+const (
+	BetFold  BetAction = "fold"
+	BetCheck BetAction = "check"
+	BetCall  BetAction = "call"
+	BetRaise BetAction = "raise"
+	BetAllIn BetAction = "all_in"
+)
+
+// PokerStreet is a betting round of a poker hand
+type PokerStreet string
+
+const (
+	StreetPreflop  PokerStreet = "preflop"
+	StreetFlop     PokerStreet = "flop"
+	StreetTurn     PokerStreet = "turn"
+	StreetRiver    PokerStreet = "river"
+	StreetShowdown PokerStreet = "showdown"
+)
+
+const (
+	pokerStartingChips = 1000
+	pokerSmallBlind    = 10
+	pokerBigBlind      = 20
+)
+
+// PokerSeat is a player's part in the current hand
+type PokerSeat struct {
+	// Bet is what the player has put in during the current street
+	Bet int `json:"bet"`
+	// Committed is what the player has put in during the whole hand
+	Committed int  `json:"committed"`
+	Folded    bool `json:"folded"`
+	AllIn     bool `json:"allIn"`
+	Acted     bool `json:"acted"`
+}
+
+// Pot is an amount of chips and the players who can win it
+type Pot struct {
+	Amount   int      `json:"amount"`
+	Eligible []string `json:"eligible"`
+}
+
+// ShowdownHand is a hand revealed at showdown
+type ShowdownHand struct {
+	PlayerID string `json:"playerId"`
+	Cards    []Card `json:"cards"`
+	Hand     string `json:"hand"`
+}
+
+// PokerState is the table state of a Texas Hold'em game. Hole cards are the
+// players' hands, community cards are dealt to the SharedZone and burnt
+// cards go to PlayedCards.
+type PokerState struct {
+	HandNumber int                   `json:"handNumber"`
+	Button     string                `json:"button"`
+	SmallBlind int                   `json:"smallBlind"`
+	BigBlind   int                   `json:"bigBlind"`
+	Street     PokerStreet           `json:"street"`
+	CurrentBet int                   `json:"currentBet"`
+	MinRaise   int                   `json:"minRaise"`
+	Seats      map[string]*PokerSeat `json:"seats"`
+	BoughtIn   map[string]bool       `json:"boughtIn"`
+	Showdown   []ShowdownHand        `json:"showdown"`
+}
+
+// pokerView is the poker state sent to clients
+type pokerView struct {
+	HandNumber int                   `json:"handNumber"`
+	Button     string                `json:"button"`
+	SmallBlind int                   `json:"smallBlind"`
+	BigBlind   int                   `json:"bigBlind"`
+	Street     PokerStreet           `json:"street"`
+	CurrentBet int                   `json:"currentBet"`
+	MinRaise   int                   `json:"minRaise"`
+	Seats      map[string]*PokerSeat `json:"seats"`
+	Pots       []Pot                 `json:"pots"`
+	Showdown   []ShowdownHand        `json:"showdown,omitempty"`
+}
+
+type HandStartedData struct {
+	HandNumber int    `json:"handNumber"`
+	Button     string `json:"button"`
+	SmallBlind string `json:"smallBlind"`
+	BigBlind   string `json:"bigBlind"`
+}
+
+type BetPlacedData struct {
+	PlayerID string    `json:"playerId"`
+	Action   BetAction `json:"action"`
+	Bet      int       `json:"bet"`
+	Chips    int       `json:"chips"`
+}
+
+type CommunityDealtData struct {
+	Street PokerStreet `json:"street"`
+	Cards  []Card      `json:"cards"`
+}
+
+type ShowdownData struct {
+	Hands []ShowdownHand `json:"hands"`
+}
+
+type PotAwardedData struct {
+	PlayerID string `json:"playerId"`
+	Amount   int    `json:"amount"`
+}
+
+// TexasHoldem is no-limit Texas Hold'em. Every deal_cards starts a new hand
+// with the button moved one seat; players are out once they lose their
+// chips and the last player with chips wins the game.
+type TexasHoldem struct{}
+
+func (TexasHoldem) Name() string { return "texas_holdem" }
+
+func (TexasHoldem) MinPlayers() int { return 2 }
+
+func (TexasHoldem) MaxPlayers() int { return 9 }
+
+// Deal starts a new hand: moves the button, posts the blinds and deals two
+// hole cards to every player with chips. Dealing a finished game starts a
+// new one, with every player buying in again.
+func (TexasHoldem) Deal(game *Game) error {
+	state := game.Poker
+	if state == nil || game.Phase == PhaseFinished {
+		state = &PokerState{
+			SmallBlind: pokerSmallBlind,
+			BigBlind:   pokerBigBlind,
+			BoughtIn:   make(map[string]bool),
+		}
+		game.Poker = state
+	} else if state.Street != StreetShowdown {
+		return errorOf(ErrRoundInProgress, "a hand is already in progress")
+	}
+
+	// New players buy in for the starting stack
+	for i := range game.Players {
+		if !state.BoughtIn[game.Players[i].ID] {
+			state.BoughtIn[game.Players[i].ID] = true
+			game.Players[i].Chips = pokerStartingChips
+		}
+	}
+
+	seated := make([]int, 0, len(game.Players))
+	for i, player := range game.Players {
+		if player.Chips > 0 {
+			seated = append(seated, i)
+		}
+	}
+
+	if len(seated) < 2 {
+		return errorOf(ErrNotEnoughPlayers, "need at least 2 players with chips")
+	}
+
+	// Move the button to the next player with chips
+	buttonIndex := findPlayer(game, state.Button)
+	state.Button = game.Players[pokerNextSeat(game, seated, buttonIndex)].ID
+	buttonIndex = findPlayer(game, state.Button)
+
+	state.HandNumber++
+	state.Street = StreetPreflop
+	state.Seats = make(map[string]*PokerSeat, len(seated))
+	state.Showdown = nil
+	for _, i := range seated {
+		state.Seats[game.Players[i].ID] = &PokerSeat{}
+	}
+
+	newDeck(game, createDeck())
+
+	for _, i := range seated {
+		hand, err := dealCards(game, 2)
+		if err != nil {
+			return err
+		}
+		game.Players[i].Hand = hand
+	}
+
+	// Heads up the button posts the small blind
+	smallBlindIndex := pokerNextSeat(game, seated, buttonIndex)
+	if len(seated) == 2 {
+		smallBlindIndex = buttonIndex
+	}
+	bigBlindIndex := pokerNextSeat(game, seated, smallBlindIndex)
+
+	pokerPostBlind(game, &game.Players[smallBlindIndex], state.SmallBlind)
+	pokerPostBlind(game, &game.Players[bigBlindIndex], state.BigBlind)
+	state.CurrentBet = state.BigBlind
+	state.MinRaise = state.BigBlind
+
+	game.Phase = PhasePlaying
+	game.emit(MsgHandStarted, HandStartedData{
+		HandNumber: state.HandNumber,
+		Button:     state.Button,
+		SmallBlind: game.Players[smallBlindIndex].ID,
+		BigBlind:   game.Players[bigBlindIndex].ID,
+	})
+
+	pokerSetNextToAct(game, bigBlindIndex)
+
+	// The blinds put everyone all-in
+	if game.CurrentPlayer == "" {
+		pokerAdvance(game, bigBlindIndex)
+	}
+	return nil
+}
+
+// ValidatePlay rejects card plays, poker is played with betting actions
+func (TexasHoldem) ValidatePlay(game *Game, player *Player, play Play) error {
+	return errorOf(ErrIllegalCard, "cards cannot be played in poker")
+}
+
+func (TexasHoldem) ApplyPlay(game *Game, player *Player, play Play) {}
+
+func (TexasHoldem) AdvanceTurn(game *Game) {}
+
+// IsOver reports whether only one player has chips left after a hand
+func (TexasHoldem) IsOver(game *Game) bool {
+	state := game.Poker
+	if state == nil || state.Street != StreetShowdown {
+		return false
+	}
+
+	withChips := 0
+	for _, player := range game.Players {
+		if player.Chips > 0 {
+			withChips++
+		}
+	}
+	return withChips <= 1
+}
+
+// Score does nothing, the players' chip stacks are their score
+func (TexasHoldem) Score(game *Game) {}
+
+// Bet applies a betting action of the player to act. For raises the amount
+// is the total the player's bet is raised to on this street.
+func (TexasHoldem) Bet(game *Game, player *Player, action BetAction, amount int) error {
+	state := game.Poker
+	if state == nil || state.Street == StreetShowdown {
+		return errorOf(ErrNotDealt, "no hand in progress")
+	}
+
+	seat := state.Seats[player.ID]
+	if seat == nil || seat.Folded || seat.AllIn {
+		return errorOf(ErrIllegalMove, "you cannot act in this hand")
+	}
+
+	toCall := state.CurrentBet - seat.Bet
+
+	switch action {
+	case BetFold:
+		seat.Folded = true
+
+	case BetCheck:
+		if toCall > 0 {
+			return errorOf(ErrInvalidBet, "cannot check facing a bet")
+		}
+
+	case BetCall:
+		if toCall == 0 {
+			return errorOf(ErrInvalidBet, "nothing to call")
+		}
+		pokerCommit(player, seat, toCall)
+
+	case BetRaise:
+		if amount <= state.CurrentBet {
+			return errorOf(ErrInvalidBet, "raise must be above the current bet")
+		}
+		if amount-seat.Bet > player.Chips {
+			return ErrNotEnoughChips
+		}
+		if amount-state.CurrentBet < state.MinRaise && amount-seat.Bet < player.Chips {
+			return errorOf(ErrInvalidBet, "raise is below the minimum raise")
+		}
+		pokerCommit(player, seat, amount-seat.Bet)
+		pokerRaiseTo(state, seat.Bet)
+
+	case BetAllIn:
+		if player.Chips == 0 {
+			return errorOf(ErrNotEnoughChips, "no chips left")
+		}
+		pokerCommit(player, seat, player.Chips)
+		if seat.Bet > state.CurrentBet {
+			pokerRaiseTo(state, seat.Bet)
+		}
+
+	default:
+		return errorOf(ErrInvalidBet, "unknown betting action")
+	}
+
+	seat.Acted = true
+	game.emit(MsgBetPlaced, BetPlacedData{
+		PlayerID: player.ID,
+		Action:   action,
+		Bet:      seat.Bet,
+		Chips:    player.Chips,
+	})
+
+	pokerAdvance(game, findPlayer(game, player.ID))
+	return nil
+}
+
+// TimeoutTurn checks for a player who runs out of time, or folds when they
+// face a bet
+func (TexasHoldem) TimeoutTurn(game *Game, player *Player) error {
+	if err := (TexasHoldem{}).Bet(game, player, BetCheck, 0); err == nil {
+		return nil
+	}
+	return TexasHoldem{}.Bet(game, player, BetFold, 0)
+}
+
+// Forfeit folds the hand of a player whose time bank has run out
+func (TexasHoldem) Forfeit(game *Game, player *Player) {
+	TexasHoldem{}.Bet(game, player, BetFold, 0)
+}
+
+// Leave folds the hand of a player who leaves the table, so the betting
+// round does not wait for them
+func (TexasHoldem) Leave(game *Game, player *Player) {
+	state := game.Poker
+	if state == nil || state.Street == StreetShowdown {
+		return
+	}
+
+	seat := state.Seats[player.ID]
+	if seat == nil || seat.Folded {
+		return
+	}
+
+	if game.CurrentPlayer == player.ID {
+		TexasHoldem{}.Bet(game, player, BetFold, 0)
+		return
+	}
+
+	seat.Folded = true
+	game.emit(MsgBetPlaced, BetPlacedData{
+		PlayerID: player.ID,
+		Action:   BetFold,
+		Bet:      seat.Bet,
+		Chips:    player.Chips,
+	})
+
+	// The hand is won if everyone else has folded
+	inHand := 0
+	for _, seat := range state.Seats {
+		if !seat.Folded {
+			inHand++
+		}
+	}
+	if inHand == 1 {
+		pokerShowdown(game)
+	}
+}
+
+// ViewState exposes the betting state, the pots and any revealed hands
+func (TexasHoldem) ViewState(game *Game, viewerID string) interface{} {
+	state := game.Poker
+	if state == nil {
+		return nil
+	}
+
+	return pokerView{
+		HandNumber: state.HandNumber,
+		Button:     state.Button,
+		SmallBlind: state.SmallBlind,
+		BigBlind:   state.BigBlind,
+		Street:     state.Street,
+		CurrentBet: state.CurrentBet,
+		MinRaise:   state.MinRaise,
+		Seats:      state.Seats,
+		Pots:       pokerPots(game),
+		Showdown:   state.Showdown,
+	}
+}
+
+// pokerAdvance moves the hand on after an action: to the next player, the
+// next street, or the end of the hand
+func pokerAdvance(game *Game, actorIndex int) {
+	state := game.Poker
+
+	inHand, canAct := 0, 0
+	for _, seat := range state.Seats {
+		if !seat.Folded {
+			inHand++
+			if !seat.AllIn {
+				canAct++
+			}
+		}
+	}
+
+	// Everyone else folded
+	if inHand == 1 {
+		pokerShowdown(game)
+		return
+	}
+
+	roundDone := true
+	for _, seat := range state.Seats {
+		if !seat.Folded && !seat.AllIn && (!seat.Acted || seat.Bet < state.CurrentBet) {
+			roundDone = false
+			break
+		}
+	}
+
+	if !roundDone {
+		pokerSetNextToAct(game, actorIndex)
+		return
+	}
+
+	// Deal the remaining streets straight away when nobody can bet
+	for {
+		if state.Street == StreetRiver {
+			pokerShowdown(game)
+			return
+		}
+
+		pokerNextStreet(game)
+		if canAct > 1 {
+			pokerSetNextToAct(game, findPlayer(game, state.Button))
+			return
+		}
+	}
+}
+
+// pokerNextStreet resets the bets and deals the next community cards
+func pokerNextStreet(game *Game) {
+	state := game.Poker
+	for _, seat := range state.Seats {
+		seat.Bet = 0
+		seat.Acted = false
+	}
+	state.CurrentBet = 0
+	state.MinRaise = state.BigBlind
+
+	count := 1
+	switch state.Street {
+	case StreetPreflop:
+		state.Street = StreetFlop
+		count = 3
+	case StreetFlop:
+		state.Street = StreetTurn
+	case StreetTurn:
+		state.Street = StreetRiver
+	}
+
+	// Burn one card before dealing
+	game.PlayedCards = append(game.PlayedCards, game.Deck[0])
+	cards := append([]Card(nil), game.Deck[1:1+count]...)
+	game.Deck = game.Deck[1+count:]
+	game.SharedZone = append(game.SharedZone, cards...)
+
+	game.emit(MsgCommunityDealt, CommunityDealtData{Street: state.Street, Cards: cards})
+}
+
+// pokerShowdown reveals the hands still in play and awards every pot to
+// the best eligible hand, splitting ties
+func pokerShowdown(game *Game) {
+	state := game.Poker
+	pots := pokerPots(game)
+
+	contenders := 0
+	for _, seat := range state.Seats {
+		if !seat.Folded {
+			contenders++
+		}
+	}
+
+	values := make(map[string]HandValue)
+	state.Showdown = make([]ShowdownHand, 0)
+	if contenders > 1 {
+		for _, player := range game.Players {
+			seat := state.Seats[player.ID]
+			if seat == nil || seat.Folded {
+				continue
+			}
+
+			cards := append(append([]Card(nil), player.Hand...), game.SharedZone...)
+			value, _ := EvaluateHand(cards)
+			values[player.ID] = value
+			state.Showdown = append(state.Showdown, ShowdownHand{
+				PlayerID: player.ID,
+				Cards:    player.Hand,
+				Hand:     value.Name(),
+			})
+		}
+		game.emit(MsgShowdown, ShowdownData{Hands: state.Showdown})
+	}
+
+	for _, pot := range pots {
+		winners := make([]string, 0, 1)
+		var best HandValue
+		for _, playerID := range pot.Eligible {
+			value := values[playerID]
+			switch {
+			case len(winners) == 0 || value.Compare(best) > 0:
+				winners = []string{playerID}
+				best = value
+			case value.Compare(best) == 0:
+				winners = append(winners, playerID)
+			}
+		}
+
+		// Odd chips go to the first winner after the button
+		share := pot.Amount / len(winners)
+		remainder := pot.Amount % len(winners)
+		for _, playerID := range pokerFromButton(game, winners) {
+			amount := share
+			if remainder > 0 {
+				amount++
+				remainder--
+			}
+			game.Players[findPlayer(game, playerID)].Chips += amount
+			game.emit(MsgPotAwarded, PotAwardedData{PlayerID: playerID, Amount: amount})
+		}
+	}
+
+	for _, seat := range state.Seats {
+		seat.Bet = 0
+	}
+	state.Street = StreetShowdown
+	state.CurrentBet = 0
+	setCurrentPlayer(game, "")
+}
+
+// pokerPots splits the chips committed this hand into the main pot and side
+// pots, each with the players who are still in and covered it
+func pokerPots(game *Game) []Pot {
+	state := game.Poker
+
+	levels := make([]int, 0, len(state.Seats))
+	for _, seat := range state.Seats {
+		if !seat.Folded && seat.Committed > 0 {
+			levels = append(levels, seat.Committed)
+		}
+	}
+	sort.Ints(levels)
+
+	pots := make([]Pot, 0, len(levels))
+	previous := 0
+	for _, level := range levels {
+		if level == previous {
+			continue
+		}
+
+		pot := Pot{Eligible: make([]string, 0)}
+		for _, seat := range state.Seats {
+			pot.Amount += min(seat.Committed, level) - min(seat.Committed, previous)
+		}
+		for _, player := range game.Players {
+			seat := state.Seats[player.ID]
+			if seat != nil && !seat.Folded && seat.Committed >= level {
+				pot.Eligible = append(pot.Eligible, player.ID)
+			}
+		}
+		pots = append(pots, pot)
+		previous = level
+	}
+
+	// Chips folded players put in above every live player's stake
+	for _, seat := range state.Seats {
+		if seat.Committed > previous && len(pots) > 0 {
+			pots[len(pots)-1].Amount += seat.Committed - previous
+		}
+	}
+
+	return pots
+}
+
+// pokerCommit moves chips from a player's stack into the pot
+func pokerCommit(player *Player, seat *PokerSeat, amount int) {
+	if amount >= player.Chips {
+		amount = player.Chips
+		seat.AllIn = true
+	}
+	player.Chips -= amount
+	seat.Bet += amount
+	seat.Committed += amount
+}
+
+// pokerRaiseTo raises the bet to match and reopens the action
+func pokerRaiseTo(state *PokerState, bet int) {
+	if bet-state.CurrentBet > state.MinRaise {
+		state.MinRaise = bet - state.CurrentBet
+	}
+	state.CurrentBet = bet
+	for _, seat := range state.Seats {
+		seat.Acted = false
+	}
+}
+
+// pokerPostBlind puts in a blind, going all-in if the stack is short
+func pokerPostBlind(game *Game, player *Player, blind int) {
+	pokerCommit(player, game.Poker.Seats[player.ID], blind)
+}
+
+// pokerSetNextToAct gives the turn to the first player after the given
+// seat who can still bet
+func pokerSetNextToAct(game *Game, fromIndex int) {
+	state := game.Poker
+	for offset := 1; offset <= len(game.Players); offset++ {
+		player := game.Players[(fromIndex+offset)%len(game.Players)]
+		seat := state.Seats[player.ID]
+		if seat != nil && !seat.Folded && !seat.AllIn {
+			setCurrentPlayer(game, player.ID)
+			return
+		}
+	}
+	setCurrentPlayer(game, "")
+}
+
+// pokerNextSeat returns the index of the first seated player after the
+// given index
+func pokerNextSeat(game *Game, seated []int, fromIndex int) int {
+	for offset := 1; offset <= len(game.Players); offset++ {
+		index := (fromIndex + offset) % len(game.Players)
+		for _, seatedIndex := range seated {
+			if seatedIndex == index {
+				return index
+			}
+		}
+	}
+	return seated[0]
+}
+
+// pokerFromButton orders players clockwise starting left of the button
+func pokerFromButton(game *Game, playerIDs []string) []string {
+	buttonIndex := findPlayer(game, game.Poker.Button)
+	ordered := make([]string, 0, len(playerIDs))
+	for offset := 1; offset <= len(game.Players); offset++ {
+		player := game.Players[(buttonIndex+offset)%len(game.Players)]
+		for _, playerID := range playerIDs {
+			if playerID == player.ID {
+				ordered = append(ordered, playerID)
+			}
+		}
+	}
+	return ordered
+}
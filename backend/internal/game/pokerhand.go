@@ -0,0 +1,137 @@
+package game
+
+import "sort"
+
+// Poker hand categories, from weakest to strongest
+const (
+	HighCard = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+)
+
+var handCategoryNames = []string{
+	"high card", "one pair", "two pair", "three of a kind", "straight",
+	"flush", "full house", "four of a kind", "straight flush",
+}
+
+// HandValue is the strength of a five card poker hand: its category
+// followed by the ranks that break ties, highest first
+type HandValue []int
+
+// Category returns the hand category, e.g. Flush
+func (v HandValue) Category() int {
+	return v[0]
+}
+
+// Name describes the hand category
+func (v HandValue) Name() string {
+	return handCategoryNames[v.Category()]
+}
+
+// Compare returns 1 if v beats other, -1 if it loses and 0 on a tie
+func (v HandValue) Compare(other HandValue) int {
+	for i := 0; i < len(v) && i < len(other); i++ {
+		if v[i] != other[i] {
+			if v[i] > other[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// EvaluateHand returns the best five card hand that can be made from the
+// given cards, usually two hole cards and five community cards
+func EvaluateHand(cards []Card) (HandValue, []Card) {
+	var best HandValue
+	var bestCards []Card
+
+	if len(cards) < 5 {
+		return best, bestCards
+	}
+
+	combo := make([]Card, 5)
+	var choose func(start, depth int)
+	choose = func(start, depth int) {
+		if depth == 5 {
+			value := evaluateFive(combo)
+			if best == nil || value.Compare(best) > 0 {
+				best = value
+				bestCards = append([]Card(nil), combo...)
+			}
+			return
+		}
+		for i := start; i <= len(cards)-(5-depth); i++ {
+			combo[depth] = cards[i]
+			choose(i+1, depth+1)
+		}
+	}
+	choose(0, 0)
+
+	return best, bestCards
+}
+
+// evaluateFive scores exactly five cards
+func evaluateFive(cards []Card) HandValue {
+	counts := make(map[int]int)
+	flush := true
+	for _, card := range cards {
+		counts[aceHighRank(card)]++
+		if card.Suit != cards[0].Suit {
+			flush = false
+		}
+	}
+
+	// Group ranks by how often they appear, then by rank
+	ranks := make([]int, 0, len(counts))
+	for rank := range counts {
+		ranks = append(ranks, rank)
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if counts[ranks[i]] != counts[ranks[j]] {
+			return counts[ranks[i]] > counts[ranks[j]]
+		}
+		return ranks[i] > ranks[j]
+	})
+
+	straightHigh := 0
+	if len(ranks) == 5 {
+		if ranks[0]-ranks[4] == 4 {
+			straightHigh = ranks[0]
+		} else if ranks[0] == 14 && ranks[1] == 5 {
+			straightHigh = 5 // Ace plays low in the wheel
+		}
+	}
+
+	category := HighCard
+	switch {
+	case straightHigh > 0 && flush:
+		category = StraightFlush
+	case counts[ranks[0]] == 4:
+		category = FourOfAKind
+	case counts[ranks[0]] == 3 && counts[ranks[1]] == 2:
+		category = FullHouse
+	case flush:
+		category = Flush
+	case straightHigh > 0:
+		category = Straight
+	case counts[ranks[0]] == 3:
+		category = ThreeOfAKind
+	case counts[ranks[0]] == 2 && counts[ranks[1]] == 2:
+		category = TwoPair
+	case counts[ranks[0]] == 2:
+		category = OnePair
+	}
+
+	if category == Straight || category == StraightFlush {
+		return HandValue{category, straightHigh}
+	}
+	return append(HandValue{category}, ranks...)
+}
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testCards builds cards from short names such as "Ah" or "10s", the rank
+// followed by the first letter of the suit. Jokers are "*".
+func testCards(names string) []Card {
+	suits := map[byte]Suit{'h': Hearts, 'd': Diamonds, 'c': Clubs, 's': Spades}
+	values := make(map[string]int)
+	for _, rank := range FrenchDeck.Ranks {
+		values[rank.Rank] = rank.Value
+	}
+
+	cards := make([]Card, 0)
+	for i, name := range strings.Fields(names) {
+		if name == "*" {
+			cards = append(cards, Card{ID: fmt.Sprintf("%s-%d", Joker, i), Suit: Joker, Rank: string(Joker)})
+			continue
+		}
+
+		rank, suit := name[:len(name)-1], suits[name[len(name)-1]]
+		cards = append(cards, Card{
+			ID:    fmt.Sprintf("%s-%s-%d", suit, rank, i),
+			Suit:  suit,
+			Rank:  rank,
+			Value: values[rank],
+		})
+	}
+	return cards
+}
+
+func TestEvaluateHand(t *testing.T) {
+	tests := []struct {
+		cards string
+		want  int
+	}{
+		{"Ah Kd 9c 7s 4h 3d 2c", HighCard},
+		{"Ah Ad 9c 7s 4h 3d 2c", OnePair},
+		{"Ah Ad 9c 9s 4h 4d 2c", TwoPair},
+		{"9h 9d 9c Ks 4h 3d 2c", ThreeOfAKind},
+		{"Ah 2d 3c 4s 5h 9d Kc", Straight},
+		{"10h Jd Qc Ks Ah 3d 2c", Straight},
+		{"Ah 9h 7h 4h 2h Kd Qc", Flush},
+		{"9h 9d 9c 4s 4h 3d 2c", FullHouse},
+		{"9h 9d 9c 9s 4h 3d 2c", FourOfAKind},
+		{"5h 6h 7h 8h 9h 9d 9c", StraightFlush},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cards, func(t *testing.T) {
+			value, best := EvaluateHand(testCards(tt.cards))
+			if value.Category() != tt.want {
+				t.Fatalf("got %s, want %s", value.Name(), handCategoryNames[tt.want])
+			}
+			if len(best) != 5 {
+				t.Fatalf("best hand has %d cards", len(best))
+			}
+		})
+	}
+}
+
+func TestCompareHands(t *testing.T) {
+	tests := []struct {
+		name  string
+		hand  string
+		other string
+		want  int
+	}{
+		{"higher category wins", "9h 9d 4c 4s 2h", "Ah Ad Kc Qs 2d", 1},
+		{"higher pair wins", "Kh Kd 4c 3s 2h", "Qh Qd Ac Js 9d", 1},
+		{"kicker breaks a tie", "Ah Ad Kc 4s 2h", "As Ac Qh Js 9d", 1},
+		{"wheel loses to six high straight", "Ah 2d 3c 4s 5h", "2h 3d 4c 5s 6h", -1},
+		{"same ranks split", "Ah Kd Qc Js 9h", "As Kh Qd Jc 9s", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hand, _ := EvaluateHand(testCards(tt.hand))
+			other, _ := EvaluateHand(testCards(tt.other))
+			if got := hand.Compare(other); got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+			if got := other.Compare(hand); got != -tt.want {
+				t.Fatalf("reversed: got %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestPokerPots(t *testing.T) {
+	tests := []struct {
+		name  string
+		seats map[string]PokerSeat
+		want  []Pot
+	}{
+		{
+			name: "even stakes make one pot",
+			seats: map[string]PokerSeat{
+				"a": {Committed: 100},
+				"b": {Committed: 100},
+				"c": {Committed: 100},
+			},
+			want: []Pot{{Amount: 300, Eligible: []string{"a", "b", "c"}}},
+		},
+		{
+			name: "short all-in makes a side pot",
+			seats: map[string]PokerSeat{
+				"a": {Committed: 50, AllIn: true},
+				"b": {Committed: 200},
+				"c": {Committed: 200},
+			},
+			want: []Pot{
+				{Amount: 150, Eligible: []string{"a", "b", "c"}},
+				{Amount: 300, Eligible: []string{"b", "c"}},
+			},
+		},
+		{
+			name: "two all-ins make two side pots",
+			seats: map[string]PokerSeat{
+				"a": {Committed: 50, AllIn: true},
+				"b": {Committed: 120, AllIn: true},
+				"c": {Committed: 300},
+				"d": {Committed: 300},
+			},
+			want: []Pot{
+				{Amount: 200, Eligible: []string{"a", "b", "c", "d"}},
+				{Amount: 210, Eligible: []string{"b", "c", "d"}},
+				{Amount: 360, Eligible: []string{"c", "d"}},
+			},
+		},
+		{
+			name: "folded chips stay in the pots",
+			seats: map[string]PokerSeat{
+				"a": {Committed: 80, Folded: true},
+				"b": {Committed: 50, AllIn: true},
+				"c": {Committed: 100},
+			},
+			want: []Pot{
+				{Amount: 150, Eligible: []string{"b", "c"}},
+				{Amount: 80, Eligible: []string{"c"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &Game{Poker: &PokerState{Seats: make(map[string]*PokerSeat)}}
+			for _, id := range []string{"a", "b", "c", "d"} {
+				if seat, ok := tt.seats[id]; ok {
+					game.Players = append(game.Players, Player{ID: id})
+					game.Poker.Seats[id] = &seat
+				}
+			}
+
+			pots := pokerPots(game)
+			if fmt.Sprint(pots) != fmt.Sprint(tt.want) {
+				t.Fatalf("got %v, want %v", pots, tt.want)
+			}
+
+			total, committed := 0, 0
+			for _, pot := range pots {
+				total += pot.Amount
+			}
+			for _, seat := range tt.seats {
+				committed += seat.Committed
+			}
+			if total != committed {
+				t.Fatalf("pots hold %d chips, %d were committed", total, committed)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package game
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// livePhases are the phases of games restored when the server starts
+const livePhases = `('waiting', 'passing', 'playing', 'paused')`
+
+// PostgresStore keeps games in the games and players tables
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Save writes the current state of a game
+func (s *PostgresStore) Save(game *Game) error {
+	return writeGame(s.db, newStoredGame(game))
+}
+
+// Delete removes a game and its players
+func (s *PostgresStore) Delete(gameID string) error {
+	_, err := s.db.Exec(`DELETE FROM games WHERE id = $1`, gameID)
+	return err
+}
+
+// LoadLive returns the games waiting for players or in play
+func (s *PostgresStore) LoadLive() ([]*Game, error) {
+	stored, err := readGames(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]*Game, 0, len(stored))
+	for _, game := range stored {
+		games = append(games, game.game())
+	}
+	return games, nil
+}
+
+// writeGame saves the game row and replaces its players in one transaction
+func writeGame(db *sql.DB, game storedGame) error {
+	state, err := json.Marshal(game.State)
+	if err != nil {
+		return err
+	}
+
+	deck, err := json.Marshal(game.Deck)
+	if err != nil {
+		return err
+	}
+	playedCards, err := json.Marshal(game.PlayedCards)
+	if err != nil {
+		return err
+	}
+	sharedZone, err := json.Marshal(game.SharedZone)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO games (id, rule_set, phase, current_player, seed, shuffles,
+			deck, played_cards, shared_zone, state, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			rule_set = EXCLUDED.rule_set,
+			phase = EXCLUDED.phase,
+			current_player = EXCLUDED.current_player,
+			seed = EXCLUDED.seed,
+			shuffles = EXCLUDED.shuffles,
+			deck = EXCLUDED.deck,
+			played_cards = EXCLUDED.played_cards,
+			shared_zone = EXCLUDED.shared_zone,
+			state = EXCLUDED.state,
+			updated_at = EXCLUDED.updated_at`,
+		game.ID, game.RuleSet, game.Phase, game.CurrentPlayer, game.Seed, game.Shuffles,
+		deck, playedCards, sharedZone, state, game.CreatedAt, game.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM players WHERE game_id = $1`, game.ID); err != nil {
+		return err
+	}
+
+	for seat, player := range game.Players {
+		hand, err := json.Marshal(player.Hand)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO players (id, game_id, seat, name, hand, score, chips,
+				is_current_player, time_bank, resume_token, connected_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			player.ID, game.ID, seat, player.Name, hand, player.Score, player.Chips,
+			player.IsCurrentPlayer, int64(player.TimeBank), player.ResumeToken, player.ConnectedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// readGames loads every live game with its players in seat order
+func readGames(db *sql.DB) ([]storedGame, error) {
+	rows, err := db.Query(`
+		SELECT id, rule_set, phase, COALESCE(current_player, ''), seed, shuffles,
+			deck, played_cards, shared_zone, state, created_at, updated_at
+		FROM games WHERE phase IN ` + livePhases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := make([]storedGame, 0)
+	for rows.Next() {
+		var game storedGame
+		var deck, playedCards, sharedZone, state []byte
+		err := rows.Scan(&game.ID, &game.RuleSet, &game.Phase, &game.CurrentPlayer, &game.Seed, &game.Shuffles,
+			&deck, &playedCards, &sharedZone, &state, &game.CreatedAt, &game.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(deck, &game.Deck); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(playedCards, &game.PlayedCards); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(sharedZone, &game.SharedZone); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(state, &game.State); err != nil {
+			return nil, err
+		}
+
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range games {
+		players, err := readPlayers(db, games[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		games[i].Players = players
+	}
+	return games, nil
+}
+
+// readPlayers loads the players of a game in seat order
+func readPlayers(db *sql.DB, gameID string) ([]storedPlayer, error) {
+	rows, err := db.Query(`
+		SELECT id, name, hand, score, chips, is_current_player, time_bank,
+			resume_token, connected_at
+		FROM players WHERE game_id = $1 ORDER BY seat`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	players := make([]storedPlayer, 0)
+	for rows.Next() {
+		var player storedPlayer
+		var hand []byte
+		var timeBank int64
+		err := rows.Scan(&player.ID, &player.Name, &hand, &player.Score, &player.Chips,
+			&player.IsCurrentPlayer, &timeBank, &player.ResumeToken, &player.ConnectedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(hand, &player.Hand); err != nil {
+			return nil, err
+		}
+		player.TimeBank = time.Duration(timeBank)
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
@@ -0,0 +1,121 @@
+package game
+
+import "fmt"
+
+// requestHistory is how many of each player's latest requests a game
+// remembers
+const requestHistory = 64
+
+// AckData confirms a client's message was handled. Duplicate is set when
+// the message had been handled already and was not applied again.
+type AckData struct {
+	Request   MessageType `json:"request"`
+	RequestID string      `json:"requestId"`
+	Duplicate bool        `json:"duplicate,omitempty"`
+}
+
+// Request names the game and player a move is made for. ID is chosen by
+// the client and optional: a move whose ID the player has used before
+// fails with ErrDuplicateRequest instead of being applied again, so a
+// message retried after a flaky connection or a reconnect takes effect
+// once. A move made with an ExpectedVersion the game has moved past fails
+// with ErrStaleVersion.
+type Request struct {
+	GameID          string
+	PlayerID        string
+	ID              string
+	ExpectedVersion int
+}
+
+// begin looks up the game a request is for and checks it has not been
+// applied already and was made against the game as it is. The manager's
+// lock must be held until the request is
+// committed or has failed.
+func (gm *GameManager) begin(request Request) (*Game, error) {
+	game, exists := gm.games[request.GameID]
+	if !exists {
+		return nil, ErrGameNotFound
+	}
+
+	if request.ID != "" {
+		for _, seen := range game.requests[request.PlayerID] {
+			if seen == request.ID {
+				return nil, ErrDuplicateRequest
+			}
+		}
+	}
+
+	if request.ExpectedVersion != 0 && game.Version != request.ExpectedVersion {
+		return nil, errorOf(ErrStaleVersion, fmt.Sprintf("game is at version %d, not %d", game.Version, request.ExpectedVersion))
+	}
+	return game, nil
+}
+
+// commit records the change a request made to a game along with the
+// request's ID. Requests that fail are not recorded, so they can be tried
+// again, and neither are changes that are undone.
+func (gm *GameManager) commit(game *Game, request Request) error {
+	if request.ID != "" && findPlayer(game, request.PlayerID) != -1 {
+		rememberRequest(game, request.PlayerID, request.ID)
+	}
+	return gm.touch(game)
+}
+
+// rememberRequest adds a request to the player's history, forgetting the
+// oldest one when it is full
+func rememberRequest(game *Game, playerID, requestID string) {
+	if game.requests == nil {
+		game.requests = make(map[string][]string)
+	}
+
+	requests := append(game.requests[playerID], requestID)
+	if len(requests) > requestHistory {
+		requests = requests[len(requests)-requestHistory:]
+	}
+	game.requests[playerID] = requests
+}
+
+// SeatRequest identifies a request that creates or joins a game. Clients
+// have no player ID until they are seated, so a retried request is told
+// apart by a session key the client keeps across reconnects along with the
+// request's ID. A seat request without either is never a duplicate.
+type SeatRequest struct {
+	SessionKey string
+	ID         string
+}
+
+// seatRecord is the game and player the latest seat request of a client
+// session led to, with no player when it created a game
+type seatRecord struct {
+	requestID string
+	gameID    string
+	playerID  string
+}
+
+// seatFor returns where a seat request led if it has been handled already
+func (gm *GameManager) seatFor(seat SeatRequest) (seatRecord, bool) {
+	if seat.SessionKey == "" || seat.ID == "" {
+		return seatRecord{}, false
+	}
+
+	record, ok := gm.seats[seat.SessionKey]
+	return record, ok && record.requestID == seat.ID
+}
+
+// recordSeat remembers where a seat request led, replacing the earlier
+// record of its session
+func (gm *GameManager) recordSeat(seat SeatRequest, gameID, playerID string) {
+	if seat.SessionKey == "" || seat.ID == "" {
+		return
+	}
+	gm.seats[seat.SessionKey] = seatRecord{requestID: seat.ID, gameID: gameID, playerID: playerID}
+}
+
+// forgetSeats drops the records of seat requests for a removed game
+func (gm *GameManager) forgetSeats(gameID string) {
+	for sessionKey, record := range gm.seats {
+		if record.gameID == gameID {
+			delete(gm.seats, sessionKey)
+		}
+	}
+}
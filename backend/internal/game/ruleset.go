@@ -0,0 +1,202 @@
+package game
+
+// DefaultRuleSet is used when a game is created without naming a ruleset
+const DefaultRuleSet = "free_play"
+
+// Play describes a card a player wants to put on the table
+type Play struct {
+	Card Card
+	// DeclaredSuit is the suit chosen when playing a wild card
+	DeclaredSuit Suit
+}
+
+// RuleSet defines the rules of a card game hosted by the GameManager.
+// Rulesets are stateless: anything they need to remember between moves is
+// stored on the Game, so a single instance serves every table.
+type RuleSet interface {
+	// Name is the identifier clients use to select the ruleset
+	Name() string
+	// MinPlayers is the number of players needed to start the game
+	MinPlayers() int
+	// MaxPlayers is the number of seats at the table
+	MaxPlayers() int
+	// Deal sets up a hand, dealing the players' cards from the deck
+	Deal(game *Game) error
+	// ValidatePlay returns an error if the play is not a legal move
+	ValidatePlay(game *Game, player *Player, play Play) error
+	// ApplyPlay puts a validated card, already taken out of the
+	// player's hand, on the table
+	ApplyPlay(game *Game, player *Player, play Play)
+	// AdvanceTurn passes the turn once a play has been applied
+	AdvanceTurn(game *Game)
+	// IsOver reports whether the game has ended
+	IsOver(game *Game) bool
+	// Score updates the players' scores once the game has ended
+	Score(game *Game)
+}
+
+// Drawer is implemented by rulesets that let the current player draw
+// cards from the deck
+type Drawer interface {
+	Draw(game *Game, player *Player) error
+}
+
+// DiscardDrawer is implemented by rulesets that let the current player
+// take the top card of the discard pile
+type DiscardDrawer interface {
+	DrawDiscard(game *Game, player *Player) error
+}
+
+// Discarder is implemented by rulesets where the current player ends their
+// turn by discarding a card instead of playing it
+type Discarder interface {
+	Discard(game *Game, player *Player, card Card) error
+}
+
+// Dropper is implemented by rulesets that let the current player move any
+// cards from their hand to the shared area. The cards have already been
+// checked to be in the player's hand.
+type Dropper interface {
+	Drop(game *Game, player *Player, cards []Card) error
+}
+
+// Melder is implemented by rulesets where cards dropped in the shared area
+// are laid down as melds or laid off onto a meld already on the table. The
+// cards have already been checked to be in the player's hand.
+type Melder interface {
+	LayDown(game *Game, player *Player, cards []Card) error
+	LayOff(game *Game, player *Player, meldID string, cards []Card) error
+}
+
+// Knocker is implemented by rulesets where the current player can end the
+// round by knocking with a final discard
+type Knocker interface {
+	Knock(game *Game, player *Player, card Card) error
+}
+
+// TurnEnder is implemented by rulesets where the current player ends
+// their turn explicitly
+type TurnEnder interface {
+	EndTurn(game *Game, player *Player) error
+}
+
+// Passer is implemented by rulesets with a passing phase before play.
+// The cards have already been checked to be in the player's hand.
+type Passer interface {
+	PassCards(game *Game, player *Player, cards []Card) error
+}
+
+// Bettor is implemented by rulesets played with chips. The amount is only
+// used by raises.
+type Bettor interface {
+	Bet(game *Game, player *Player, action BetAction, amount int) error
+}
+
+// Wagerer is implemented by rulesets where players bet before a round
+type Wagerer interface {
+	PlaceBet(game *Game, player *Player, amount int) error
+}
+
+// HandActor is implemented by rulesets where the current player acts on
+// their hand instead of playing cards, such as blackjack
+type HandActor interface {
+	HandAction(game *Game, player *Player, action HandAction) error
+}
+
+// Singer is implemented by rulesets where players score by declaring
+// cards held in hand, such as the pairs of Tute
+type Singer interface {
+	Sing(game *Game, player *Player, suit Suit) error
+}
+
+// Caller is implemented by rulesets where players raise the stakes with
+// calls the other side must answer, such as Truco
+type Caller interface {
+	Call(game *Game, player *Player, call TrucoCall) error
+}
+
+// RoundScorer is implemented by rulesets whose game is one round that can
+// be played as part of a match. ScoreRound returns the points each player
+// earned in a round that is over, without adding them to their scores.
+type RoundScorer interface {
+	ScoreRound(game *Game) map[string]int
+}
+
+// TurnTimeouter is implemented by rulesets that choose the move made for a
+// current player who runs out of time, such as folding at poker
+type TurnTimeouter interface {
+	TimeoutTurn(game *Game, player *Player) error
+}
+
+// PassTimeouter is implemented by rulesets that pass cards for a player
+// who has not passed in time. It fails for a player who has passed already.
+type PassTimeouter interface {
+	TimeoutPass(game *Game, player *Player) error
+}
+
+// Forfeiter is implemented by rulesets that decide what happens to a
+// player whose time bank runs out. Without it the game ends.
+type Forfeiter interface {
+	Forfeit(game *Game, player *Player)
+}
+
+// OptionsChecker is implemented by rulesets that reject table options
+// they cannot be played with
+type OptionsChecker interface {
+	CheckOptions(options GameOptions) error
+}
+
+// Leaver is implemented by rulesets that settle the part a player had in
+// the hand in play when they leave the table, such as folding their poker
+// hand. It is called while the player is still seated.
+type Leaver interface {
+	Leave(game *Game, player *Player)
+}
+
+// StateViewer is implemented by rulesets that expose their own state in
+// the game view. The returned value must not leak hidden information to
+// the viewer.
+type StateViewer interface {
+	ViewState(game *Game, viewerID string) interface{}
+}
+
+var ruleSets = map[string]RuleSet{}
+
+func init() {
+	RegisterRuleSet(FreePlay{})
+	RegisterRuleSet(CrazyEights{})
+	RegisterRuleSet(HeartsGame{})
+	RegisterRuleSet(TexasHoldem{})
+	RegisterRuleSet(Blackjack{})
+	RegisterRuleSet(Brisca{})
+	RegisterRuleSet(Tute{})
+	RegisterRuleSet(Truco{})
+	RegisterRuleSet(GinRummy{})
+}
+
+// RegisterRuleSet makes a ruleset available to games by its name
+func RegisterRuleSet(rules RuleSet) {
+	ruleSets[rules.Name()] = rules
+}
+
+// GetRuleSet looks up a ruleset by name, falling back to the default
+// ruleset when no name is given
+func GetRuleSet(name string) (RuleSet, error) {
+	if name == "" {
+		name = DefaultRuleSet
+	}
+
+	rules, exists := ruleSets[name]
+	if !exists {
+		return nil, errorOf(ErrUnknownRuleSet, "unknown ruleset: "+name)
+	}
+	return rules, nil
+}
+
+// rulesFor returns the ruleset a game is played with
+func rulesFor(game *Game) RuleSet {
+	if rules, exists := ruleSets[game.RuleSet]; exists {
+		return rules
+	}
+	return ruleSets[DefaultRuleSet]
+}
@@ -0,0 +1,180 @@
+package game
+
+import "time"
+
+// defaultReconnectGrace is how long, in seconds, the seat of a disconnected
+// player is held when the table does not set it
+const defaultReconnectGrace = 30
+
+// SessionData is sent to a player when they join or resume, with the
+// token that lets them take their seat back after a disconnect
+type SessionData struct {
+	GameID      string `json:"gameId"`
+	PlayerID    string `json:"playerId"`
+	ResumeToken string `json:"resumeToken"`
+}
+
+type ResumeSessionData struct {
+	ResumeToken string `json:"resumeToken"`
+}
+
+type PlayerConnectionData struct {
+	PlayerID string `json:"playerId"`
+}
+
+// DisconnectPlayer holds the seat of a player whose connection dropped for
+// the table's grace period. A game in play is paused until every player is
+// back, and the player leaves the game if they have not resumed in time.
+func (gm *GameManager) DisconnectPlayer(gameID, playerID string) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		return nil, ErrGameNotFound
+	}
+
+	playerIndex := findPlayer(game, playerID)
+	if playerIndex == -1 {
+		return nil, ErrPlayerNotFound
+	}
+
+	player := &game.Players[playerIndex]
+	if !player.Connected {
+		return game, nil
+	}
+
+	gm.holdSeat(game, player)
+	game.emit(MsgPlayerDisconnected, PlayerConnectionData{PlayerID: playerID})
+
+	if err := gm.touch(game); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// ResumeSession gives a player their seat back with the token they were
+// given when joining
+func (gm *GameManager) ResumeSession(gameID, resumeToken string) (*Game, *Player, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		return nil, nil, ErrGameNotFound
+	}
+
+	playerIndex := -1
+	for i, player := range game.Players {
+		if resumeToken != "" && player.ResumeToken == resumeToken {
+			playerIndex = i
+			break
+		}
+	}
+
+	if playerIndex == -1 {
+		return nil, nil, ErrInvalidResumeToken
+	}
+
+	player := &game.Players[playerIndex]
+	if !player.Connected {
+		player.Connected = true
+		player.DisconnectedAt = time.Time{}
+		if timer, ok := game.graceTimers[player.ID]; ok {
+			timer.Stop()
+			delete(game.graceTimers, player.ID)
+		}
+		game.emit(MsgPlayerReconnected, PlayerConnectionData{PlayerID: player.ID})
+		resumeAfterReconnect(game)
+	}
+
+	if err := gm.touch(game); err != nil {
+		return nil, nil, err
+	}
+	resumed := *player
+	return game, &resumed, nil
+}
+
+// expireGrace removes a player who did not come back in time. Timers from
+// an earlier disconnect are ignored.
+func (gm *GameManager) expireGrace(gameID, playerID string, since time.Time) {
+	gm.mutex.Lock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		gm.mutex.Unlock()
+		return
+	}
+
+	playerIndex := findPlayer(game, playerID)
+	if playerIndex == -1 || game.Players[playerIndex].Connected ||
+		!game.Players[playerIndex].DisconnectedAt.Equal(since) {
+		gm.mutex.Unlock()
+		return
+	}
+
+	delete(game.graceTimers, playerID)
+	game.emit(MsgPlayerLeft, PlayerLeftData{PlayerID: playerID})
+	if _, err := gm.removePlayer(game, playerIndex); err != nil {
+		gm.recordFailure(game, err)
+	}
+
+	finished := game.Phase == PhaseFinished
+	handler := gm.onUpdate
+	gm.mutex.Unlock()
+
+	if handler != nil {
+		handler(gameID, finished)
+	}
+}
+
+// holdSeat marks a player as disconnected and keeps their seat for the
+// grace period, pausing a game in play until they are back
+func (gm *GameManager) holdSeat(game *Game, player *Player) {
+	now := time.Now()
+	player.Connected = false
+	player.DisconnectedAt = now
+
+	if game.Phase == PhasePlaying || game.Phase == PhasePassing {
+		game.PausedPhase = game.Phase
+		game.Phase = PhasePaused
+		game.reconnectPause = true
+	}
+
+	if game.graceTimers == nil {
+		game.graceTimers = make(map[string]*time.Timer)
+	}
+	gameID, playerID := game.ID, player.ID
+	grace := time.Duration(game.Options.ReconnectGrace) * time.Second
+	game.graceTimers[playerID] = time.AfterFunc(grace, func() {
+		gm.expireGrace(gameID, playerID, now)
+	})
+}
+
+// resumeAfterReconnect ends a pause made for disconnected players once they
+// are all back
+func resumeAfterReconnect(game *Game) {
+	if !game.reconnectPause || game.Phase != PhasePaused {
+		return
+	}
+
+	for _, player := range game.Players {
+		if !player.Connected {
+			return
+		}
+	}
+
+	game.Phase = game.PausedPhase
+	game.PausedPhase = ""
+	game.reconnectPause = false
+}
+
+// stopTimers cancels every timer of a game that is being removed
+func stopTimers(game *Game) {
+	stopTurnTimer(game)
+	stopClock(game, time.Now())
+	for playerID, timer := range game.graceTimers {
+		timer.Stop()
+		delete(game.graceTimers, playerID)
+	}
+}
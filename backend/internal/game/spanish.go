@@ -0,0 +1,61 @@
+package game
+
+// spanishTrickOrder ranks Spanish deck cards in Brisca and Tute, where the
+// ace and the three beat the court cards
+var spanishTrickOrder = map[string]int{
+	"1": 10, "3": 9, "12": 8, "11": 7, "10": 6, "7": 5, "6": 4, "5": 3, "4": 2, "2": 1,
+}
+
+// spanishCardPoints is the trick point value of a card in Brisca and Tute.
+// A deck holds 120 points.
+func spanishCardPoints(card Card) int {
+	switch card.Rank {
+	case "1":
+		return 11
+	case "3":
+		return 10
+	case "12":
+		return 4
+	case "11":
+		return 3
+	case "10":
+		return 2
+	default:
+		return 0
+	}
+}
+
+// spanishBeats reports whether a card beats the best card of a trick so far
+func spanishBeats(card, best Card, trump Suit) bool {
+	if card.Suit == best.Suit {
+		return spanishTrickOrder[card.Rank] > spanishTrickOrder[best.Rank]
+	}
+	return card.Suit == trump
+}
+
+// spanishTrickWinner returns the index of the card that wins a trick: the
+// highest trump, or else the highest card of the suit led
+func spanishTrickWinner(cards []Card, trump Suit) int {
+	winner := 0
+	for i := 1; i < len(cards); i++ {
+		if spanishBeats(cards[i], cards[winner], trump) {
+			winner = i
+		}
+	}
+	return winner
+}
+
+// newSpanishDeck builds the deck for a Spanish trick game. With three
+// players a two is removed so the cards deal out evenly.
+func newSpanishDeck(players int) []Card {
+	deck := NewDeck(SpanishDeck)
+	if players == 3 {
+		for i, card := range deck {
+			if card.Suit == Oros && card.Rank == "2" {
+				deck = append(deck[:i], deck[i+1:]...)
+				break
+			}
+		}
+	}
+	return deck
+}
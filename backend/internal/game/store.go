@@ -0,0 +1,353 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// GameStore keeps games so they outlive the server process. The manager
+// saves a game on every change and loads the live ones when it starts.
+type GameStore interface {
+	// Save writes the current state of a game
+	Save(game *Game) error
+	// Delete removes a game that has been cleaned up
+	Delete(gameID string) error
+	// LoadLive returns every game that was waiting for players or in play
+	LoadLive() ([]*Game, error)
+}
+
+// gameState is the part of a game stored as a single JSON document: the
+// table options and the state kept by the match layer and the rulesets.
+// New ruleset state must be added here to survive a restart.
+type gameState struct {
+	Options        GameOptions         `json:"options"`
+	Melds          []Meld              `json:"melds,omitempty"`
+	Match          *MatchState         `json:"match,omitempty"`
+	Fairness       *FairShuffle        `json:"fairness,omitempty"`
+	PausedPhase    GamePhase           `json:"pausedPhase,omitempty"`
+	ReconnectPause bool                `json:"reconnectPause,omitempty"`
+	Pauses         map[string]int      `json:"pauses,omitempty"`
+	Requests       map[string][]string `json:"requests,omitempty"`
+	Version        int                 `json:"version"`
+	CrazyEights    *CrazyEightsState   `json:"crazyEights,omitempty"`
+	Hearts         *HeartsState        `json:"hearts,omitempty"`
+	Poker          *PokerState         `json:"poker,omitempty"`
+	Blackjack      *BlackjackState     `json:"blackjack,omitempty"`
+	Brisca         *BriscaState        `json:"brisca,omitempty"`
+	Tute           *TuteState          `json:"tute,omitempty"`
+	Truco          *TrucoState         `json:"truco,omitempty"`
+	GinRummy       *GinRummyState      `json:"ginRummy,omitempty"`
+}
+
+// storedGame is a game as it is written to a store, including the deck,
+// the players' hands and their resume tokens
+type storedGame struct {
+	ID            string         `json:"id"`
+	RuleSet       string         `json:"ruleSet"`
+	Phase         GamePhase      `json:"phase"`
+	CurrentPlayer string         `json:"currentPlayer"`
+	Seed          int64          `json:"seed"`
+	Shuffles      int            `json:"shuffles"`
+	Deck          []Card         `json:"deck"`
+	PlayedCards   []Card         `json:"playedCards"`
+	SharedZone    []Card         `json:"sharedZone"`
+	State         gameState      `json:"state"`
+	Players       []storedPlayer `json:"players"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+type storedPlayer struct {
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	Hand            []Card        `json:"hand"`
+	Score           int           `json:"score"`
+	Chips           int           `json:"chips"`
+	IsCurrentPlayer bool          `json:"isCurrentPlayer"`
+	TimeBank        time.Duration `json:"timeBank"`
+	ResumeToken     string        `json:"resumeToken"`
+	ConnectedAt     time.Time     `json:"connectedAt"`
+}
+
+// newStoredGame copies the state of a game to be written to a store
+func newStoredGame(game *Game) storedGame {
+	stored := storedGame{
+		ID:            game.ID,
+		RuleSet:       game.RuleSet,
+		Phase:         game.Phase,
+		CurrentPlayer: game.CurrentPlayer,
+		Seed:          game.Seed,
+		Shuffles:      game.Shuffles,
+		Deck:          game.Deck,
+		PlayedCards:   game.PlayedCards,
+		SharedZone:    game.SharedZone,
+		State: gameState{
+			Options:        game.Options,
+			Melds:          game.Melds,
+			Match:          game.Match,
+			Fairness:       game.Fairness,
+			PausedPhase:    game.PausedPhase,
+			ReconnectPause: game.reconnectPause,
+			Pauses:         game.Pauses,
+			Requests:       game.requests,
+			Version:        game.Version,
+			CrazyEights:    game.CrazyEights,
+			Hearts:         game.Hearts,
+			Poker:          game.Poker,
+			Blackjack:      game.Blackjack,
+			Brisca:         game.Brisca,
+			Tute:           game.Tute,
+			Truco:          game.Truco,
+			GinRummy:       game.GinRummy,
+		},
+		Players:   make([]storedPlayer, 0, len(game.Players)),
+		CreatedAt: game.CreatedAt,
+		UpdatedAt: game.UpdatedAt,
+	}
+
+	for _, player := range game.Players {
+		stored.Players = append(stored.Players, storedPlayer{
+			ID:              player.ID,
+			Name:            player.Name,
+			Hand:            player.Hand,
+			Score:           player.Score,
+			Chips:           player.Chips,
+			IsCurrentPlayer: player.IsCurrentPlayer,
+			TimeBank:        player.TimeBank,
+			ResumeToken:     player.ResumeToken,
+			ConnectedAt:     player.ConnectedAt,
+		})
+	}
+	return stored
+}
+
+// game rebuilds the game from its stored state
+func (s storedGame) game() *Game {
+	game := &Game{
+		ID:             s.ID,
+		RuleSet:        s.RuleSet,
+		Options:        s.State.Options,
+		Seed:           s.Seed,
+		Shuffles:       s.Shuffles,
+		Fairness:       s.State.Fairness,
+		Players:        make([]Player, 0, len(s.Players)),
+		CurrentPlayer:  s.CurrentPlayer,
+		Phase:          s.Phase,
+		Deck:           s.Deck,
+		PlayedCards:    s.PlayedCards,
+		SharedZone:     s.SharedZone,
+		Melds:          s.State.Melds,
+		Match:          s.State.Match,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+		CrazyEights:    s.State.CrazyEights,
+		Hearts:         s.State.Hearts,
+		Poker:          s.State.Poker,
+		Blackjack:      s.State.Blackjack,
+		Brisca:         s.State.Brisca,
+		Tute:           s.State.Tute,
+		Truco:          s.State.Truco,
+		GinRummy:       s.State.GinRummy,
+		PausedPhase:    s.State.PausedPhase,
+		reconnectPause: s.State.ReconnectPause,
+		Pauses:         s.State.Pauses,
+		requests:       s.State.Requests,
+		Version:        s.State.Version,
+	}
+
+	for _, player := range s.Players {
+		game.Players = append(game.Players, Player{
+			ID:              player.ID,
+			Name:            player.Name,
+			Hand:            player.Hand,
+			Score:           player.Score,
+			Chips:           player.Chips,
+			IsCurrentPlayer: player.IsCurrentPlayer,
+			TimeBank:        player.TimeBank,
+			ResumeToken:     player.ResumeToken,
+			ConnectedAt:     player.ConnectedAt,
+		})
+	}
+	return game
+}
+
+// isLive reports whether a stored game should be restored on startup
+func isLive(phase GamePhase) bool {
+	return phase == PhaseWaiting || phase == PhasePassing || phase == PhasePlaying || phase == PhasePaused
+}
+
+// MemoryStore keeps games in memory, for development and tests. Games are
+// stored as JSON so what is loaded is a copy, as it would be from a
+// database.
+type MemoryStore struct {
+	games map[string][]byte
+	mutex sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		games: make(map[string][]byte),
+	}
+}
+
+// Save writes the current state of a game
+func (s *MemoryStore) Save(game *Game) error {
+	data, err := json.Marshal(newStoredGame(game))
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.games[game.ID] = data
+	return nil
+}
+
+// Delete removes a game
+func (s *MemoryStore) Delete(gameID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.games, gameID)
+	return nil
+}
+
+// LoadLive returns copies of the games waiting for players or in play
+func (s *MemoryStore) LoadLive() ([]*Game, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	games := make([]*Game, 0)
+	for _, data := range s.games {
+		var stored storedGame
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, err
+		}
+		if isLive(stored.Phase) {
+			games = append(games, stored.game())
+		}
+	}
+	return games, nil
+}
+
+// saveGame takes a snapshot of a game on every change, while the manager's
+// lock is held, and hands it to the store writer. A change that has to be
+// undone is restored from the snapshot.
+func (gm *GameManager) saveGame(game *Game) {
+	saved, err := json.Marshal(newStoredGame(game))
+	if err != nil {
+		log.Printf("Error saving game %s: %v", game.ID, err)
+		return
+	}
+	game.saved = saved
+	game.savedEvents = len(game.events)
+
+	gm.writer.save(game.ID, saved)
+}
+
+// undoChange puts a game back as it was last saved. What is not saved is
+// kept: the timers, the players' connections and the turn count, and the
+// turn timer and clocks are restarted for the restored turn. Players who
+// left during the undone change get their seat back as disconnected.
+func (gm *GameManager) undoChange(game *Game) {
+	if game.saved == nil {
+		return
+	}
+
+	var stored storedGame
+	if err := json.Unmarshal(game.saved, &stored); err != nil {
+		log.Printf("Error restoring game %s: %v", game.ID, err)
+		return
+	}
+
+	live := *game
+	*game = *stored.game()
+	game.TurnDeadline = live.TurnDeadline
+	game.turn, game.timedTurn, game.turnTimer, game.turnLeft = live.turn, live.timedTurn, live.turnTimer, live.turnLeft
+	game.clockPlayer, game.clockTurn, game.clockStarted, game.clockTimer = live.clockPlayer, live.clockTurn, live.clockStarted, live.clockTimer
+	game.graceTimers = live.graceTimers
+	game.saved, game.savedEvents = live.saved, live.savedEvents
+	if live.savedEvents <= len(live.events) {
+		game.events = live.events[:live.savedEvents]
+	}
+
+	for i := range game.Players {
+		player := &game.Players[i]
+		if j := findPlayer(&live, player.ID); j != -1 {
+			player.Connected = live.Players[j].Connected
+			player.DisconnectedAt = live.Players[j].DisconnectedAt
+		} else {
+			gm.holdSeat(game, player)
+		}
+	}
+
+	now := time.Now()
+	gm.runClock(game, now)
+	gm.scheduleTurn(game)
+}
+
+// deleteGame removes a game from the store once its queued writes are done
+func (gm *GameManager) deleteGame(gameID string) {
+	gm.writer.delete(gameID)
+}
+
+// RestoreGames loads the games that were still live when the server
+// stopped. Their players count as disconnected, so each keeps their seat
+// for the grace period and can take it back with their resume token. The
+// games that cannot be restored are skipped and their errors returned.
+func (gm *GameManager) RestoreGames() error {
+	games, err := gm.store.LoadLive()
+	if err != nil {
+		return err
+	}
+
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	var failed []error
+	restored := 0
+	for _, game := range games {
+		if _, exists := gm.games[game.ID]; exists {
+			continue
+		}
+		if err := gm.restoreGame(game); err != nil {
+			failed = append(failed, fmt.Errorf("game %s: %w", game.ID, err))
+			continue
+		}
+		restored++
+	}
+
+	log.Printf("Restored %d of %d games", restored, len(games))
+	return errors.Join(failed...)
+}
+
+// restoreGame puts a loaded game back in play. Rows stored without their
+// options, like the seeded test game, get the defaults of their ruleset,
+// and games whose options are invalid are deleted. A game whose cards do
+// not add up is left in the store as it is.
+func (gm *GameManager) restoreGame(game *Game) error {
+	rules, err := GetRuleSet(game.RuleSet)
+	if err == nil {
+		game.Options, err = resolveOptions(rules, game.Options)
+	}
+	if err != nil {
+		gm.deleteGame(game.ID)
+		return err
+	}
+	game.RuleSet = game.Options.RuleSet
+
+	if err := checkCards(game); err != nil {
+		return fmt.Errorf("%w: %v", ErrCardsMismatch, err)
+	}
+
+	for i := range game.Players {
+		gm.holdSeat(game, &game.Players[i])
+	}
+	gm.games[game.ID] = game
+	return gm.touch(game)
+}
@@ -0,0 +1,229 @@
+package game
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+// timeoutMoves caps the moves made for a player who ran out of time before
+// their turn is skipped
+const timeoutMoves = 3
+
+type TurnTimeoutData struct {
+	PlayerID string `json:"playerId"`
+}
+
+// UpdateHandler is called after the manager changes a game on its own,
+// when a player runs out of time or does not reconnect, once the manager's
+// lock has been released, so the clients of the game can be updated
+type UpdateHandler func(gameID string, finished bool)
+
+// SetUpdateHandler sets who is told about games changed by the manager's
+// timers
+func (gm *GameManager) SetUpdateHandler(handler UpdateHandler) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	gm.onUpdate = handler
+}
+
+// touch records a change to a game, checks that no card was lost or
+// copied, runs the players' clocks, starts the turn timer when the turn has
+// passed to another player and saves the game. A change that lost or
+// copied cards is undone instead and ErrCardsMismatch returned.
+func (gm *GameManager) touch(game *Game) error {
+	if err := checkCards(game); err != nil {
+		log.Printf("Cards of game %s do not add up, undoing the change: %v", game.ID, err)
+		gm.undoChange(game)
+		return ErrCardsMismatch
+	}
+
+	now := time.Now()
+	game.UpdatedAt = now
+	game.Version++
+	gm.runClock(game, now)
+	gm.scheduleTurn(game)
+	gm.saveGame(game)
+	return nil
+}
+
+// recordFailure keeps the error of a change made by a timer, which has no
+// client to report it to, so the store error shows it until the game is
+// saved again
+func (gm *GameManager) recordFailure(game *Game, err error) {
+	log.Printf("Change to game %s failed: %v", game.ID, err)
+	gm.writer.fail(game.ID, err)
+}
+
+// scheduleTurn starts the timer of the current turn on tables with a turn
+// timer. The timer is keyed by the turn, not the seat, so it carries on
+// when players leave and the seats move.
+func (gm *GameManager) scheduleTurn(game *Game) {
+	if game.Options.TurnTimer == 0 {
+		return
+	}
+
+	if !timedPhase(game) {
+		// A paused turn keeps the time it had left for when play resumes
+		if game.Phase == PhasePaused && game.TurnDeadline != nil {
+			game.turnLeft = max(time.Until(*game.TurnDeadline), time.Millisecond)
+		}
+		stopTurnTimer(game)
+		return
+	}
+
+	if game.turnTimer != nil && game.timedTurn == game.turn {
+		return
+	}
+
+	stopTurnTimer(game)
+	duration := time.Duration(game.Options.TurnTimer) * time.Second
+	if game.turnLeft > 0 && game.timedTurn == game.turn {
+		duration = game.turnLeft
+	}
+	game.turnLeft = 0
+	deadline := time.Now().Add(duration)
+	game.TurnDeadline = &deadline
+	game.timedTurn = game.turn
+
+	gameID, turn := game.ID, game.turn
+	game.turnTimer = time.AfterFunc(duration, func() {
+		gm.expireTurn(gameID, turn)
+	})
+}
+
+// timedPhase reports whether a game waits on a move against the clock:
+// the current player's in play, or everyone's pass before a round when the
+// ruleset can pass for them
+func timedPhase(game *Game) bool {
+	if game.Phase == PhasePassing {
+		_, ok := rulesFor(game).(PassTimeouter)
+		return ok
+	}
+	return game.Phase == PhasePlaying && game.CurrentPlayer != ""
+}
+
+// stopTurnTimer cancels the timer of the current turn
+func stopTurnTimer(game *Game) {
+	if game.turnTimer != nil {
+		game.turnTimer.Stop()
+		game.turnTimer = nil
+	}
+	game.TurnDeadline = nil
+}
+
+// expireTurn makes the default move for a player whose time has run out,
+// or passes for the players who have not passed in time. Timers that fire
+// after their turn has been played are ignored.
+func (gm *GameManager) expireTurn(gameID string, turn int) {
+	gm.mutex.Lock()
+
+	game, exists := gm.games[gameID]
+	if !exists || game.turn != turn || !timedPhase(game) {
+		gm.mutex.Unlock()
+		return
+	}
+
+	rules := rulesFor(game)
+	if game.Phase == PhasePassing {
+		game.turnTimer = nil
+		timeoutPass(game, rules.(PassTimeouter))
+	} else {
+		playerIndex := findPlayer(game, game.CurrentPlayer)
+		if playerIndex == -1 {
+			gm.mutex.Unlock()
+			return
+		}
+
+		game.turnTimer = nil
+		player := &game.Players[playerIndex]
+		game.emit(MsgTurnTimeout, TurnTimeoutData{PlayerID: player.ID})
+		timeoutTurn(game, rules, player)
+	}
+	finishIfOver(game, rules)
+	if err := gm.touch(game); err != nil {
+		gm.recordFailure(game, err)
+	}
+
+	finished := game.Phase == PhaseFinished
+	handler := gm.onUpdate
+	gm.mutex.Unlock()
+
+	if handler != nil {
+		handler(gameID, finished)
+	}
+}
+
+// timeoutPass passes for each player still to pass, until play starts
+func timeoutPass(game *Game, timeouter PassTimeouter) {
+	for i := 0; i < len(game.Players) && game.Phase == PhasePassing; i++ {
+		player := &game.Players[i]
+		if timeouter.TimeoutPass(game, player) == nil {
+			game.emit(MsgTurnTimeout, TurnTimeoutData{PlayerID: player.ID})
+		}
+	}
+}
+
+// timeoutTurn moves for a player who ran out of time. Rulesets may choose
+// the move themselves; otherwise the lowest card the player can play is
+// played, drawing or ending the turn when there is none, and the turn is
+// skipped when nothing else works. A turn the ruleset fails to move on is
+// skipped too, so its timer does not fire over and over.
+func timeoutTurn(game *Game, rules RuleSet, player *Player) {
+	turn := game.turn
+	if timeouter, ok := rules.(TurnTimeouter); ok {
+		err := timeouter.TimeoutTurn(game, player)
+		if err != nil && game.turn == turn && game.Phase == PhasePlaying {
+			log.Printf("No move made for %s in game %s, skipping their turn: %v", player.ID, game.ID, err)
+			nextPlayer(game)
+		}
+		return
+	}
+
+	for move := 0; move < timeoutMoves && game.turn == turn && game.Phase == PhasePlaying; move++ {
+		if autoPlay(game, rules, player) {
+			continue
+		}
+		if drawer, ok := rules.(Drawer); ok && drawer.Draw(game, player) == nil {
+			continue
+		}
+		if ender, ok := rules.(TurnEnder); ok && ender.EndTurn(game, player) == nil {
+			continue
+		}
+		break
+	}
+
+	if game.turn == turn && game.Phase == PhasePlaying {
+		nextPlayer(game)
+	}
+}
+
+// autoPlay plays the lowest valued card the ruleset accepts from the
+// player's hand. Wild cards name their own suit.
+func autoPlay(game *Game, rules RuleSet, player *Player) bool {
+	hand := append([]Card{}, player.Hand...)
+	sort.SliceStable(hand, func(i, j int) bool {
+		return hand[i].Value < hand[j].Value
+	})
+
+	for _, card := range hand {
+		play := Play{Card: card, DeclaredSuit: card.Suit}
+		if rules.ValidatePlay(game, player, play) != nil {
+			continue
+		}
+
+		game.emit(MsgCardPlayed, CardPlayedData{PlayerID: player.ID, Card: card})
+		applyPlay(game, rules, player, play)
+		return true
+	}
+	return false
+}
+
+// applyPlay takes a validated card out of the player's hand, puts it on
+// the table and passes the turn
+func applyPlay(game *Game, rules RuleSet, player *Player, play Play) {
+	player.Hand = removeCard(player.Hand, play.Card.ID)
+	rules.ApplyPlay(game, player, play)
+	rules.AdvanceTurn(game)
+}
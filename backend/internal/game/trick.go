@@ -0,0 +1,56 @@
+package game
+
+// TrickCard is a card played to a trick together with who played it
+type TrickCard struct {
+	PlayerID string `json:"playerId"`
+	Card     Card   `json:"card"`
+}
+
+// TrickWonData is sent when a trick has been resolved
+type TrickWonData struct {
+	WinnerID string      `json:"winnerId"`
+	Cards    []TrickCard `json:"cards"`
+	Points   int         `json:"points"`
+}
+
+// aceHighRank ranks a French deck card with the ace above the king
+func aceHighRank(card Card) int {
+	if card.Rank == "A" {
+		return 14
+	}
+	return card.Value
+}
+
+// hasSuit reports whether a hand holds any card of the given suit
+func hasSuit(hand []Card, suit Suit) bool {
+	for _, card := range hand {
+		if card.Suit == suit {
+			return true
+		}
+	}
+	return false
+}
+
+// trickCards pairs the cards of the trick in the SharedZone with the
+// players who played them
+func trickCards(game *Game, trickPlayers []string) []TrickCard {
+	trick := make([]TrickCard, len(game.SharedZone))
+	for i, card := range game.SharedZone {
+		trick[i] = TrickCard{PlayerID: trickPlayers[i], Card: card}
+	}
+	return trick
+}
+
+// takeTrick moves the trick in the SharedZone to PlayedCards, announces
+// the winner and gives them the lead. It returns the winner's ID.
+func takeTrick(game *Game, trickPlayers []string, winner int, points int) string {
+	winnerID := trickPlayers[winner]
+	trick := trickCards(game, trickPlayers)
+
+	game.PlayedCards = append(game.PlayedCards, game.SharedZone...)
+	game.SharedZone = make([]Card, 0)
+
+	game.emit(MsgTrickWon, TrickWonData{WinnerID: winnerID, Cards: trick, Points: points})
+	setCurrentPlayer(game, winnerID)
+	return winnerID
+}
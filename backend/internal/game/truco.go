@@ -0,0 +1,660 @@
+package game
+
+// TrucoCall is a call or an answer to a call in Truco
+type TrucoCall string
+
+const (
+	CallEnvido      TrucoCall = "envido"
+	CallRealEnvido  TrucoCall = "real_envido"
+	CallFaltaEnvido TrucoCall = "falta_envido"
+	CallTruco       TrucoCall = "truco"
+	CallRetruco     TrucoCall = "retruco"
+	CallValeCuatro  TrucoCall = "vale_cuatro"
+	CallQuiero      TrucoCall = "quiero"
+	CallNoQuiero    TrucoCall = "no_quiero"
+)
+
+const (
+	trucoHandSize  = 3
+	trucoShortGame = 15
+	trucoLongGame  = 30
+	trucoNoTeam    = -1
+)
+
+// TrucoPending is a call waiting for an answer from the other team. Calls
+// holds the whole envido chain, or the truco raises made so far.
+type TrucoPending struct {
+	Calls      []TrucoCall `json:"calls"`
+	CallerID   string      `json:"callerId"`
+	CallerTeam int         `json:"callerTeam"`
+}
+
+// TrucoState is the table state of a Truco game. Players sitting in even
+// seats form team 0 and the others team 1.
+type TrucoState struct {
+	Target       int      `json:"target"`
+	Scores       [2]int   `json:"scores"`
+	Hand         int      `json:"hand"`
+	Mano         string   `json:"mano"`
+	TrickPlayers []string `json:"trickPlayers"`
+	// Tricks is the team that won each trick so far, or -1 for a tie
+	Tricks []int `json:"tricks"`
+	// TrucoValue is what the hand is worth and TrucoHolder the team that
+	// accepted the last truco call, the only one allowed to raise it, or -1
+	// before any truco
+	TrucoValue   int  `json:"trucoValue"`
+	TrucoHolder  int  `json:"trucoHolder"`
+	EnvidoClosed bool `json:"envidoClosed"`
+	// Pending is the call to answer. A truco call answered with envido is
+	// kept in Deferred until the envido is settled.
+	Pending  *TrucoPending `json:"pending,omitempty"`
+	Deferred *TrucoPending `json:"deferred,omitempty"`
+}
+
+// trucoView is the Truco state sent to clients
+type trucoView struct {
+	Target      int           `json:"target"`
+	Scores      [2]int        `json:"scores"`
+	Teams       [2][]string   `json:"teams"`
+	Hand        int           `json:"hand"`
+	Mano        string        `json:"mano"`
+	Trick       []TrickCard   `json:"trick"`
+	Tricks      []int         `json:"tricks"`
+	TrucoValue  int           `json:"trucoValue"`
+	TrucoHolder int           `json:"trucoHolder"`
+	EnvidoOpen  bool          `json:"envidoOpen"`
+	Pending     *TrucoPending `json:"pending,omitempty"`
+	Deferred    *TrucoPending `json:"deferred,omitempty"`
+}
+
+type CallMadeData struct {
+	PlayerID string    `json:"playerId"`
+	Call     TrucoCall `json:"call"`
+}
+
+type PointsScoredData struct {
+	Team   int    `json:"team"`
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+	// PlayerID and Envido show the winning envido when one was accepted
+	PlayerID string `json:"playerId,omitempty"`
+	Envido   int    `json:"envido,omitempty"`
+}
+
+// Truco is Argentine Truco for two players or teams of two or three, played
+// with the Spanish deck. Each hand is three cards played in up to three
+// tricks, and the team that wins two takes the hand. Players raise the
+// stakes with truco, retruco and vale cuatro, bet on their best pair of a
+// suit with the envido calls, and the other team answers quiero or no
+// quiero. Heads-up games are played to 15 points and team games to 30.
+type Truco struct{}
+
+func (Truco) Name() string { return "truco" }
+
+func (Truco) MinPlayers() int { return 2 }
+
+func (Truco) MaxPlayers() int { return 6 }
+
+// Deal starts a new game, with the first player to join as mano
+func (Truco) Deal(game *Game) error {
+	if game.Truco != nil && game.Phase != PhaseFinished {
+		return errorOf(ErrRoundInProgress, "a game is already in progress")
+	}
+
+	if len(game.Players)%2 != 0 {
+		return errorOf(ErrIllegalMove, "truco is played by two teams of the same size")
+	}
+
+	target := trucoLongGame
+	if len(game.Players) == 2 {
+		target = trucoShortGame
+	}
+
+	game.Truco = &TrucoState{Target: target}
+	for i := range game.Players {
+		game.Players[i].Score = 0
+	}
+
+	return trucoDealHand(game)
+}
+
+// ValidatePlay refuses cards while a call is waiting for an answer
+func (Truco) ValidatePlay(game *Game, player *Player, play Play) error {
+	state := game.Truco
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if state.Pending != nil {
+		return errorOf(ErrIllegalMove, "a call is waiting for an answer")
+	}
+	return nil
+}
+
+// ApplyPlay adds the card to the current trick
+func (Truco) ApplyPlay(game *Game, player *Player, play Play) {
+	game.SharedZone = append(game.SharedZone, play.Card)
+	game.Truco.TrickPlayers = append(game.Truco.TrickPlayers, player.ID)
+}
+
+// AdvanceTurn passes the turn and resolves the trick once everyone has
+// played, scoring the hand when it has been decided
+func (Truco) AdvanceTurn(game *Game) {
+	state := game.Truco
+	if len(game.SharedZone) < len(game.Players) {
+		nextPlayer(game)
+		return
+	}
+
+	// The highest card wins unless both teams played one of equal rank
+	winner := 0
+	team := trucoTeam(game, state.TrickPlayers[0])
+	for i, card := range game.SharedZone {
+		cardTeam := trucoTeam(game, state.TrickPlayers[i])
+		switch best := game.SharedZone[winner]; {
+		case trucoRank(card) > trucoRank(best):
+			winner, team = i, cardTeam
+		case trucoRank(card) == trucoRank(best) && cardTeam != team:
+			team = trucoNoTeam
+		}
+	}
+
+	if team == trucoNoTeam {
+		// A tied trick has no winner and is led again by the same player
+		trick := trickCards(game, state.TrickPlayers)
+		game.PlayedCards = append(game.PlayedCards, game.SharedZone...)
+		game.SharedZone = make([]Card, 0)
+		game.emit(MsgTrickWon, TrickWonData{Cards: trick})
+		setCurrentPlayer(game, state.TrickPlayers[0])
+	} else {
+		takeTrick(game, state.TrickPlayers, winner, 0)
+	}
+
+	state.TrickPlayers = make([]string, 0)
+	state.Tricks = append(state.Tricks, team)
+	state.EnvidoClosed = true
+
+	if handWinner, decided := trucoHandWinner(state.Tricks, trucoTeam(game, state.Mano)); decided {
+		trucoEndHand(game, handWinner, state.TrucoValue, "truco")
+	}
+}
+
+// IsOver reports whether a team has reached the target score
+func (Truco) IsOver(game *Game) bool {
+	state := game.Truco
+	if state == nil {
+		return false
+	}
+	return state.Scores[0] >= state.Target || state.Scores[1] >= state.Target
+}
+
+// Score does nothing as points are scored as hands and envidos are won
+func (Truco) Score(game *Game) {}
+
+// Call makes a call or answers the call pending from the other team. New
+// calls are made by the current player; any player of the other team may
+// answer.
+func (Truco) Call(game *Game, player *Player, call TrucoCall) error {
+	state := game.Truco
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	team := trucoTeam(game, player.ID)
+
+	if state.Pending == nil {
+		if call == CallQuiero || call == CallNoQuiero {
+			return errorOf(ErrIllegalMove, "there is no call to answer")
+		}
+
+		if game.CurrentPlayer != player.ID {
+			return ErrNotYourTurn
+		}
+
+		if trucoIsEnvido(call) {
+			if !trucoCanEnvido(game, player) {
+				return errorOf(ErrIllegalMove, "envido can no longer be called")
+			}
+		} else if trucoCallValue(call) != state.TrucoValue+1 ||
+			(state.TrucoValue > 1 && state.TrucoHolder != team) {
+			return errorOf(ErrIllegalMove, "you cannot make that call now")
+		}
+
+		state.Pending = &TrucoPending{
+			Calls:      []TrucoCall{call},
+			CallerID:   player.ID,
+			CallerTeam: team,
+		}
+		game.emit(MsgCallMade, CallMadeData{PlayerID: player.ID, Call: call})
+		return nil
+	}
+
+	pending := state.Pending
+	if team == pending.CallerTeam {
+		return errorOf(ErrNotYourTurn, "waiting for the other team to answer")
+	}
+
+	last := pending.Calls[len(pending.Calls)-1]
+
+	switch {
+	case call == CallQuiero:
+		game.emit(MsgCallMade, CallMadeData{PlayerID: player.ID, Call: call})
+		if trucoIsEnvido(last) {
+			trucoSettleEnvido(game, true)
+			return nil
+		}
+		state.TrucoValue = trucoCallValue(last)
+		state.TrucoHolder = team
+		state.EnvidoClosed = true
+		state.Pending = nil
+
+	case call == CallNoQuiero:
+		game.emit(MsgCallMade, CallMadeData{PlayerID: player.ID, Call: call})
+		if trucoIsEnvido(last) {
+			trucoSettleEnvido(game, false)
+			return nil
+		}
+		trucoEndHand(game, pending.CallerTeam, state.TrucoValue, "no_quiero")
+
+	case trucoIsEnvido(last) && trucoIsEnvido(call):
+		if !trucoCanRaiseEnvido(pending.Calls, call) {
+			return errorOf(ErrIllegalMove, "you cannot make that call now")
+		}
+		pending.Calls = append(pending.Calls, call)
+		pending.CallerID, pending.CallerTeam = player.ID, team
+		game.emit(MsgCallMade, CallMadeData{PlayerID: player.ID, Call: call})
+
+	case trucoIsEnvido(call):
+		// Envido goes first: it can answer a truco call
+		if last != CallTruco || !trucoCanEnvido(game, player) {
+			return errorOf(ErrIllegalMove, "envido can no longer be called")
+		}
+		state.Deferred = pending
+		state.Pending = &TrucoPending{
+			Calls:      []TrucoCall{call},
+			CallerID:   player.ID,
+			CallerTeam: team,
+		}
+		game.emit(MsgCallMade, CallMadeData{PlayerID: player.ID, Call: call})
+
+	default:
+		// Raising the truco accepts the call that was made
+		if trucoCallValue(call) != trucoCallValue(last)+1 {
+			return errorOf(ErrIllegalMove, "you cannot make that call now")
+		}
+		state.TrucoValue = trucoCallValue(last)
+		state.EnvidoClosed = true
+		pending.Calls = append(pending.Calls, call)
+		pending.CallerID, pending.CallerTeam = player.ID, team
+		game.emit(MsgCallMade, CallMadeData{PlayerID: player.ID, Call: call})
+	}
+
+	return nil
+}
+
+// TimeoutTurn refuses the call a player who runs out of time should answer,
+// or the next player when the call is their own, and otherwise plays their
+// lowest card
+func (Truco) TimeoutTurn(game *Game, player *Player) error {
+	state := game.Truco
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if state.Pending != nil {
+		answering := player
+		if trucoTeam(game, player.ID) == state.Pending.CallerTeam {
+			answering = &game.Players[(findPlayer(game, player.ID)+1)%len(game.Players)]
+		}
+		return Truco{}.Call(game, answering, CallNoQuiero)
+	}
+
+	if !autoPlay(game, Truco{}, player) {
+		return errorOf(ErrIllegalCard, "no card can be played")
+	}
+	return nil
+}
+
+// Forfeit gives the game to the other team of a player whose time bank has
+// run out
+func (Truco) Forfeit(game *Game, player *Player) {
+	state := game.Truco
+	if state == nil {
+		return
+	}
+
+	team := 1 - trucoTeam(game, player.ID)
+	trucoAward(game, team, state.Target-state.Scores[team], PointsScoredData{Reason: "forfeit", PlayerID: player.ID})
+}
+
+// Leave forfeits the game for the team of a player who leaves, as the
+// teams cannot be made up again
+func (Truco) Leave(game *Game, player *Player) {
+	Truco{}.Forfeit(game, player)
+}
+
+// ViewState exposes the scores, the trick in play and the calls made
+func (Truco) ViewState(game *Game, viewerID string) interface{} {
+	state := game.Truco
+	if state == nil {
+		return nil
+	}
+
+	var teams [2][]string
+	for i, player := range game.Players {
+		teams[i%2] = append(teams[i%2], player.ID)
+	}
+
+	return trucoView{
+		Target:      state.Target,
+		Scores:      state.Scores,
+		Teams:       teams,
+		Hand:        state.Hand,
+		Mano:        state.Mano,
+		Trick:       trickCards(game, state.TrickPlayers),
+		Tricks:      state.Tricks,
+		TrucoValue:  state.TrucoValue,
+		TrucoHolder: state.TrucoHolder,
+		EnvidoOpen:  !state.EnvidoClosed,
+		Pending:     state.Pending,
+		Deferred:    state.Deferred,
+	}
+}
+
+// trucoDealHand deals three cards to each player, with the mano moving one
+// seat on every hand
+func trucoDealHand(game *Game) error {
+	state := game.Truco
+
+	manoIndex := 0
+	if state.Hand > 0 {
+		manoIndex = (findPlayer(game, state.Mano) + 1) % len(game.Players)
+	}
+
+	state.Hand++
+	state.Mano = game.Players[manoIndex].ID
+	state.TrickPlayers = make([]string, 0)
+	state.Tricks = make([]int, 0)
+	state.TrucoValue = 1
+	state.TrucoHolder = trucoNoTeam
+	state.EnvidoClosed = false
+	state.Pending = nil
+	state.Deferred = nil
+
+	newDeck(game, NewDeck(SpanishDeck))
+
+	if err := dealHands(game, trucoHandSize); err != nil {
+		return err
+	}
+
+	game.Phase = PhasePlaying
+	setCurrentPlayer(game, state.Mano)
+	return nil
+}
+
+// trucoEndHand scores the hand and deals the next one unless the game is
+// over
+func trucoEndHand(game *Game, team, points int, reason string) {
+	trucoAward(game, team, points, PointsScoredData{Reason: reason})
+
+	game.PlayedCards = append(game.PlayedCards, game.SharedZone...)
+	game.SharedZone = make([]Card, 0)
+
+	if !(Truco{}).IsOver(game) {
+		trucoDealHand(game)
+	}
+}
+
+// trucoAward adds points to a team and mirrors the team score on its players
+func trucoAward(game *Game, team, points int, data PointsScoredData) {
+	state := game.Truco
+	state.Scores[team] += points
+
+	for i := range game.Players {
+		if i%2 == team {
+			game.Players[i].Score = state.Scores[team]
+		}
+	}
+
+	data.Team, data.Points = team, points
+	game.emit(MsgPointsScored, data)
+}
+
+// trucoSettleEnvido scores the pending envido chain once it is answered and
+// brings back a truco call it interrupted
+func trucoSettleEnvido(game *Game, accepted bool) {
+	state := game.Truco
+	pending := state.Pending
+
+	state.EnvidoClosed = true
+	state.Pending = state.Deferred
+	state.Deferred = nil
+
+	if !accepted {
+		// The callers score what was accepted before the last raise
+		points := 0
+		for _, call := range pending.Calls[:len(pending.Calls)-1] {
+			points += trucoEnvidoValue(game, call)
+		}
+		if points == 0 {
+			points = 1
+		}
+		trucoAward(game, pending.CallerTeam, points, PointsScoredData{Reason: "no_quiero"})
+		return
+	}
+
+	points := 0
+	for _, call := range pending.Calls {
+		if call == CallFaltaEnvido {
+			points = trucoEnvidoValue(game, call)
+			break
+		}
+		points += trucoEnvidoValue(game, call)
+	}
+
+	// Ties go to the player closest to the mano
+	manoIndex := findPlayer(game, state.Mano)
+	winner := -1
+	best := -1
+	for offset := range game.Players {
+		i := (manoIndex + offset) % len(game.Players)
+		if score := trucoEnvido(game, game.Players[i]); score > best {
+			winner, best = i, score
+		}
+	}
+
+	trucoAward(game, winner%2, points, PointsScoredData{
+		Reason:   "envido",
+		PlayerID: game.Players[winner].ID,
+		Envido:   best,
+	})
+}
+
+// trucoEnvido is a player's envido: 20 plus the two best cards of a suit
+// when they hold two of the same suit, or else their best card. Court cards
+// count as zero. A card already played to the first trick still counts.
+func trucoEnvido(game *Game, player Player) int {
+	cards := append([]Card{}, player.Hand...)
+	for i, id := range game.Truco.TrickPlayers {
+		if id == player.ID {
+			cards = append(cards, game.SharedZone[i])
+		}
+	}
+
+	values := make(map[Suit][]int)
+	best := 0
+	for _, card := range cards {
+		value := card.Value
+		if value > 7 {
+			value = 0
+		}
+		values[card.Suit] = append(values[card.Suit], value)
+		if value > best {
+			best = value
+		}
+	}
+
+	for _, suitValues := range values {
+		if len(suitValues) < 2 {
+			continue
+		}
+		first, second := 0, 0
+		for _, value := range suitValues {
+			if value > first {
+				first, second = value, first
+			} else if value > second {
+				second = value
+			}
+		}
+		if score := 20 + first + second; score > best {
+			best = score
+		}
+	}
+
+	return best
+}
+
+// trucoHandWinner works out the team taking the hand from the tricks played
+// so far. A tied trick is decided by the tricks around it, and a hand of
+// three ties goes to the mano's team.
+func trucoHandWinner(tricks []int, manoTeam int) (int, bool) {
+	wins := [2]int{}
+	for _, team := range tricks {
+		if team != trucoNoTeam {
+			wins[team]++
+			if wins[team] == 2 {
+				return team, true
+			}
+		}
+	}
+
+	switch len(tricks) {
+	case 2:
+		if tricks[0] == trucoNoTeam && tricks[1] != trucoNoTeam {
+			return tricks[1], true
+		}
+		if tricks[0] != trucoNoTeam && tricks[1] == trucoNoTeam {
+			return tricks[0], true
+		}
+	case 3:
+		for _, team := range []int{tricks[2], tricks[0], tricks[1]} {
+			if team != trucoNoTeam {
+				return team, true
+			}
+		}
+		return manoTeam, true
+	}
+
+	return 0, false
+}
+
+// trucoCanEnvido reports whether a player may still call envido: only
+// during the first trick, before they play their card and before a truco
+// has been accepted
+func trucoCanEnvido(game *Game, player *Player) bool {
+	state := game.Truco
+	return !state.EnvidoClosed && len(state.Tricks) == 0 && len(player.Hand) == trucoHandSize
+}
+
+// trucoCanRaiseEnvido reports whether a call may follow an envido chain:
+// envido at most twice, real envido once and falta envido last
+func trucoCanRaiseEnvido(calls []TrucoCall, call TrucoCall) bool {
+	counts := make(map[TrucoCall]int)
+	for _, made := range calls {
+		counts[made]++
+	}
+
+	if counts[CallFaltaEnvido] > 0 {
+		return false
+	}
+
+	switch call {
+	case CallEnvido:
+		return counts[CallRealEnvido] == 0 && counts[CallEnvido] < 2
+	case CallRealEnvido:
+		return counts[CallRealEnvido] == 0
+	default:
+		return true
+	}
+}
+
+// trucoIsEnvido reports whether a call belongs to the envido chain
+func trucoIsEnvido(call TrucoCall) bool {
+	return call == CallEnvido || call == CallRealEnvido || call == CallFaltaEnvido
+}
+
+// trucoEnvidoValue is what an envido call adds. Falta envido is worth what
+// the leading team is missing to win.
+func trucoEnvidoValue(game *Game, call TrucoCall) int {
+	switch call {
+	case CallEnvido:
+		return 2
+	case CallRealEnvido:
+		return 3
+	default:
+		state := game.Truco
+		leader := state.Scores[0]
+		if state.Scores[1] > leader {
+			leader = state.Scores[1]
+		}
+		return state.Target - leader
+	}
+}
+
+// trucoCallValue is what the hand is worth once a truco call is accepted
+func trucoCallValue(call TrucoCall) int {
+	switch call {
+	case CallTruco:
+		return 2
+	case CallRetruco:
+		return 3
+	case CallValeCuatro:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// trucoTeam returns the team of a player, by seat
+func trucoTeam(game *Game, playerID string) int {
+	return findPlayer(game, playerID) % 2
+}
+
+// trucoRank ranks a card in trick play: the ace of espadas, the ace of
+// bastos, the seven of espadas and the seven of oros, then the threes,
+// twos, the other aces and so on down to the fours
+func trucoRank(card Card) int {
+	switch {
+	case card.Rank == "1" && card.Suit == Espadas:
+		return 14
+	case card.Rank == "1" && card.Suit == Bastos:
+		return 13
+	case card.Rank == "7" && card.Suit == Espadas:
+		return 12
+	case card.Rank == "7" && card.Suit == Oros:
+		return 11
+	}
+
+	switch card.Rank {
+	case "3":
+		return 10
+	case "2":
+		return 9
+	case "1":
+		return 8
+	case "12":
+		return 7
+	case "11":
+		return 6
+	case "10":
+		return 5
+	case "7":
+		return 4
+	case "6":
+		return 3
+	case "5":
+		return 2
+	default:
+		return 1
+	}
+}
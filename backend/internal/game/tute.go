@@ -0,0 +1,278 @@
+package game
+
+const (
+	tuteLastTrickBonus = 10
+	tutePairPoints     = 20
+	tuteTrumpPair      = 40
+)
+
+// TuteState is the table state of a Tute game
+type TuteState struct {
+	Trump        Card     `json:"trump"`
+	TrickPlayers []string `json:"trickPlayers"`
+	TricksPlayed int      `json:"tricksPlayed"`
+	// LastWinner may sing a pair before leading the next trick
+	LastWinner string `json:"lastWinner"`
+	CanSing    bool   `json:"canSing"`
+	Sung       []Suit `json:"sung"`
+	// Tute is the player who won the game by holding all four kings or
+	// all four horses
+	Tute string `json:"tute,omitempty"`
+}
+
+// tuteView is the Tute state sent to clients
+type tuteView struct {
+	Trump      Card        `json:"trump"`
+	Trick      []TrickCard `json:"trick"`
+	LastWinner string      `json:"lastWinner"`
+	CanSing    bool        `json:"canSing"`
+	Sung       []Suit      `json:"sung"`
+	Tute       string      `json:"tute,omitempty"`
+}
+
+// SingData names the suit of the pair sung, or none for all four kings
+// or horses
+type SingData struct {
+	Suit Suit `json:"suit,omitempty"`
+}
+
+type PairSungData struct {
+	PlayerID string `json:"playerId"`
+	Suit     Suit   `json:"suit,omitempty"`
+	Points   int    `json:"points"`
+	Tute     bool   `json:"tute"`
+}
+
+// Tute is played with the Spanish deck dealt out entirely. The last card
+// dealt sets the trump. Players must follow suit and beat the winning card
+// when they can, and must trump when void in the suit led. The winner of a
+// trick may sing a king and horse pair for 20 points, or 40 in trumps,
+// holding all four kings or horses wins outright, and the last trick is
+// worth 10 extra points.
+type Tute struct{}
+
+func (Tute) Name() string { return "tute" }
+
+func (Tute) MinPlayers() int { return 3 }
+
+func (Tute) MaxPlayers() int { return 4 }
+
+// Deal deals the whole deck and turns up the last card as trump
+func (Tute) Deal(game *Game) error {
+	if game.Tute != nil && game.Phase != PhaseFinished {
+		return errorOf(ErrRoundInProgress, "a game is already in progress")
+	}
+
+	newDeck(game, newSpanishDeck(len(game.Players)))
+
+	handSize := len(game.Deck) / len(game.Players)
+	for i := range game.Players {
+		hand, err := dealCards(game, handSize)
+		if err != nil {
+			return err
+		}
+		game.Players[i].Hand = hand
+		game.Players[i].Score = 0
+	}
+
+	dealerHand := game.Players[len(game.Players)-1].Hand
+	game.Tute = &TuteState{
+		Trump:        dealerHand[len(dealerHand)-1],
+		TrickPlayers: make([]string, 0),
+		Sung:         make([]Suit, 0),
+	}
+
+	game.Phase = PhasePlaying
+	setCurrentPlayer(game, game.Players[0].ID)
+	return nil
+}
+
+// ValidatePlay enforces following suit, beating the winning card when
+// possible and trumping when void in the suit led
+func (Tute) ValidatePlay(game *Game, player *Player, play Play) error {
+	state := game.Tute
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if len(game.SharedZone) == 0 {
+		return nil
+	}
+
+	trump := state.Trump.Suit
+	lead := game.SharedZone[0].Suit
+	best := game.SharedZone[spanishTrickWinner(game.SharedZone, trump)]
+	card := play.Card
+
+	canBeat := func(suit Suit) bool {
+		for _, held := range player.Hand {
+			if held.Suit == suit && spanishBeats(held, best, trump) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasSuit(player.Hand, lead) {
+		if card.Suit != lead {
+			return errorOf(ErrIllegalCard, "you must follow suit")
+		}
+		if best.Suit == lead && canBeat(lead) && !spanishBeats(card, best, trump) {
+			return errorOf(ErrIllegalCard, "you must beat the winning card")
+		}
+		return nil
+	}
+
+	if hasSuit(player.Hand, trump) {
+		if best.Suit != trump && card.Suit != trump {
+			return errorOf(ErrIllegalCard, "you must play a trump")
+		}
+		if best.Suit == trump && canBeat(trump) && !spanishBeats(card, best, trump) {
+			return errorOf(ErrIllegalCard, "you must beat the winning trump")
+		}
+	}
+
+	return nil
+}
+
+// ApplyPlay adds the card to the current trick
+func (Tute) ApplyPlay(game *Game, player *Player, play Play) {
+	state := game.Tute
+	game.SharedZone = append(game.SharedZone, play.Card)
+	state.TrickPlayers = append(state.TrickPlayers, player.ID)
+	state.CanSing = false
+}
+
+// AdvanceTurn passes the turn and resolves the trick once everyone has
+// played
+func (Tute) AdvanceTurn(game *Game) {
+	state := game.Tute
+	if len(game.SharedZone) < len(game.Players) {
+		nextPlayer(game)
+		return
+	}
+
+	winner := spanishTrickWinner(game.SharedZone, state.Trump.Suit)
+	points := 0
+	for _, card := range game.SharedZone {
+		points += spanishCardPoints(card)
+	}
+
+	state.TricksPlayed++
+	if len(game.Players[0].Hand) == 0 {
+		points += tuteLastTrickBonus
+	}
+
+	winnerID := takeTrick(game, state.TrickPlayers, winner, points)
+	state.TrickPlayers = make([]string, 0)
+	state.LastWinner = winnerID
+	state.CanSing = true
+
+	game.Players[findPlayer(game, winnerID)].Score += points
+}
+
+// IsOver reports whether every card has been played or a player has sung
+// tute
+func (Tute) IsOver(game *Game) bool {
+	state := game.Tute
+	if state == nil {
+		return false
+	}
+
+	if state.Tute != "" {
+		return true
+	}
+
+	if len(game.SharedZone) > 0 {
+		return false
+	}
+
+	for _, player := range game.Players {
+		if len(player.Hand) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Score does nothing as trick and pair points are scored as they are won
+func (Tute) Score(game *Game) {}
+
+// Sing declares a king and horse pair of a suit after winning a trick. An
+// empty suit declares tute: all four kings or all four horses.
+func (Tute) Sing(game *Game, player *Player, suit Suit) error {
+	state := game.Tute
+	if state == nil {
+		return ErrNotDealt
+	}
+
+	if !state.CanSing || state.LastWinner != player.ID {
+		return errorOf(ErrIllegalMove, "only the winner of the last trick can sing")
+	}
+
+	if suit == "" {
+		for _, rank := range []string{"12", "11"} {
+			count := 0
+			for _, card := range player.Hand {
+				if card.Rank == rank {
+					count++
+				}
+			}
+			if count == len(SpanishDeck.Suits) {
+				state.Tute = player.ID
+				state.CanSing = false
+				game.emit(MsgPairSung, PairSungData{PlayerID: player.ID, Tute: true})
+				return nil
+			}
+		}
+		return errorOf(ErrIllegalMove, "you do not hold all four kings or horses")
+	}
+
+	for _, sung := range state.Sung {
+		if sung == suit {
+			return errorOf(ErrIllegalMove, "this suit has already been sung")
+		}
+	}
+
+	hasKing, hasHorse := false, false
+	for _, card := range player.Hand {
+		if card.Suit == suit && card.Rank == "12" {
+			hasKing = true
+		}
+		if card.Suit == suit && card.Rank == "11" {
+			hasHorse = true
+		}
+	}
+
+	if !hasKing || !hasHorse {
+		return errorOf(ErrIllegalMove, "you do not hold the king and horse of that suit")
+	}
+
+	points := tutePairPoints
+	if suit == state.Trump.Suit {
+		points = tuteTrumpPair
+	}
+
+	player.Score += points
+	state.Sung = append(state.Sung, suit)
+	state.CanSing = false
+	game.emit(MsgPairSung, PairSungData{PlayerID: player.ID, Suit: suit, Points: points})
+	return nil
+}
+
+// ViewState exposes the trump, the trick in play and the pairs sung
+func (Tute) ViewState(game *Game, viewerID string) interface{} {
+	state := game.Tute
+	if state == nil {
+		return nil
+	}
+
+	return tuteView{
+		Trump:      state.Trump,
+		Trick:      trickCards(game, state.TrickPlayers),
+		LastWinner: state.LastWinner,
+		CanSing:    state.CanSing,
+		Sung:       state.Sung,
+		Tute:       state.Tute,
+	}
+}
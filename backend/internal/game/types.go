@@ -1,12 +1,14 @@
 package game
 
 import (
+	"encoding/json"
 	"time"
 	"github.com/google/uuid"
 )
 
 type Suit string
 
+// French suits
 const (
 	Hearts   Suit = "hearts"
 	Diamonds Suit = "diamonds"
@@ -14,6 +16,14 @@ const (
 	Spades   Suit = "spades"
 )
 
+// Spanish suits
+const (
+	Oros    Suit = "oros"
+	Copas   Suit = "copas"
+	Espadas Suit = "espadas"
+	Bastos  Suit = "bastos"
+)
+
 type Card struct {
 	ID    string `json:"id"`
 	Suit  Suit   `json:"suit"`
@@ -26,7 +36,15 @@ type Player struct {
 	Name            string `json:"name"`
 	Hand            []Card `json:"hand"`
 	Score           int    `json:"score"`
+	Chips           int    `json:"chips"`
 	IsCurrentPlayer bool   `json:"isCurrentPlayer"`
+	// TimeBank is the time left on the player's clock when it last stopped
+	TimeBank        time.Duration `json:"timeBank"`
+	// Connected is false while the seat is held for a player whose
+	// connection dropped. ResumeToken lets them take it back.
+	Connected       bool      `json:"connected"`
+	ResumeToken     string    `json:"-"`
+	DisconnectedAt  time.Time `json:"-"`
 	ConnectedAt     time.Time `json:"-"`
 }
 
@@ -34,64 +52,220 @@ type GamePhase string
 
 const (
 	PhaseWaiting  GamePhase = "waiting"
+	PhasePassing  GamePhase = "passing"
 	PhasePlaying  GamePhase = "playing"
 	PhaseFinished GamePhase = "finished"
+	PhasePaused   GamePhase = "paused"
 )
 
 type Game struct {
 	ID            string    `json:"id"`
+	RuleSet       string    `json:"ruleSet"`
+	Options       GameOptions `json:"options"`
+	// Seed drives every shuffle of the game, Shuffles counts them
+	Seed          int64     `json:"seed"`
+	Shuffles      int       `json:"shuffles"`
+	Fairness      *FairShuffle `json:"fairness,omitempty"`
 	Players       []Player  `json:"players"`
 	CurrentPlayer string    `json:"currentPlayer"`
 	Phase         GamePhase `json:"gamePhase"`
 	Deck          []Card    `json:"-"`
 	PlayedCards   []Card    `json:"playedCards"`
 	SharedZone    []Card    `json:"sharedZone"`
+	Melds         []Meld    `json:"melds,omitempty"`
+	Match         *MatchState `json:"match,omitempty"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
+	// Version goes up by one every time the game changes
+	Version       int       `json:"version"`
+
+	// Ruleset specific state
+	CrazyEights *CrazyEightsState `json:"crazyEights,omitempty"`
+	Hearts      *HeartsState      `json:"hearts,omitempty"`
+	Poker       *PokerState       `json:"poker,omitempty"`
+	Blackjack   *BlackjackState   `json:"blackjack,omitempty"`
+	Brisca      *BriscaState      `json:"brisca,omitempty"`
+	Tute        *TuteState        `json:"tute,omitempty"`
+	Truco       *TrucoState       `json:"truco,omitempty"`
+	GinRummy    *GinRummyState    `json:"ginRummy,omitempty"`
+
+	// TurnDeadline is when the current player's time runs out, on tables
+	// with a turn timer
+	TurnDeadline *time.Time `json:"turnDeadline,omitempty"`
+	// PausedPhase is the phase a paused game returns to
+	PausedPhase GamePhase `json:"pausedPhase,omitempty"`
+	// Pauses counts the times each player has paused the game
+	Pauses map[string]int `json:"pauses,omitempty"`
+
+	events []Event
+	// turn counts the turns taken. The turn timer belongs to timedTurn.
+	turn      int
+	timedTurn int
+	turnTimer *time.Timer
+	// turnLeft is the time the timed turn had left when the game was paused
+	turnLeft time.Duration
+	// clockPlayer's time bank has been running since clockStarted, during
+	// turn clockTurn
+	clockPlayer  string
+	clockTurn    int
+	clockStarted time.Time
+	clockTimer   *time.Timer
+	// graceTimers hold the seats of disconnected players, and
+	// reconnectPause is set while the game waits for them
+	graceTimers    map[string]*time.Timer
+	reconnectPause bool
+	// cardCount is how many cards the game's deck had
+	cardCount int
+	// saved is the game as it was last saved, with savedEvents of its
+	// events, to undo a change that lost or copied cards
+	saved       []byte
+	savedEvents int
+	// requests are the IDs of each player's latest requests, oldest first
+	requests map[string][]string
 }
 
 // Message types for WebSocket communication
 type MessageType string
 
 const (
+	MsgCreateGame      MessageType = "create_game"
 	MsgJoinGame        MessageType = "join_game"
+	MsgResumeSession   MessageType = "resume_session"
+	MsgPauseGame       MessageType = "pause_game"
+	MsgResumeGame      MessageType = "resume_game"
 	MsgLeaveGame       MessageType = "leave_game"
 	MsgPlayCard        MessageType = "play_card"
 	MsgDealCards       MessageType = "deal_cards"
 	MsgDropCardShared  MessageType = "drop_card_shared"
+	MsgDrawCard        MessageType = "draw_card"
+	MsgDiscardCard     MessageType = "discard_card"
+	MsgPassCards       MessageType = "pass_cards"
+	MsgFold            MessageType = "fold"
+	MsgCheck           MessageType = "check"
+	MsgCall            MessageType = "call"
+	MsgRaise           MessageType = "raise"
+	MsgAllIn           MessageType = "all_in"
+	MsgPlaceBet        MessageType = "place_bet"
+	MsgHit             MessageType = "hit"
+	MsgStand           MessageType = "stand"
+	MsgDouble          MessageType = "double"
+	MsgSplit           MessageType = "split"
+	MsgSing            MessageType = "sing"
+	MsgEnvido          MessageType = "envido"
+	MsgRealEnvido      MessageType = "real_envido"
+	MsgFaltaEnvido     MessageType = "falta_envido"
+	MsgTruco           MessageType = "truco"
+	MsgRetruco         MessageType = "retruco"
+	MsgValeCuatro      MessageType = "vale_cuatro"
+	MsgQuiero          MessageType = "quiero"
+	MsgNoQuiero        MessageType = "no_quiero"
+	MsgKnock           MessageType = "knock"
+	MsgEndTurn         MessageType = "end_turn"
 	MsgGameState       MessageType = "game_state"
 	MsgPlayerJoined    MessageType = "player_joined"
 	MsgPlayerLeft      MessageType = "player_left"
+	MsgPlayerDisconnected MessageType = "player_disconnected"
+	MsgPlayerReconnected  MessageType = "player_reconnected"
+	MsgSession         MessageType = "session"
 	MsgCardPlayed      MessageType = "card_played"
 	MsgCardsDealt      MessageType = "cards_dealt"
 	MsgCardDropped     MessageType = "card_dropped"
 	MsgGameEnded       MessageType = "game_ended"
+	MsgCardDrawn       MessageType = "card_drawn"
+	MsgCardDiscarded   MessageType = "card_discarded"
+	MsgDeckReshuffled  MessageType = "deck_reshuffled"
+	MsgShuffleCommitted MessageType = "shuffle_committed"
+	MsgSuitDeclared    MessageType = "suit_declared"
+	MsgCardsPassed     MessageType = "cards_passed"
+	MsgCardsReceived   MessageType = "cards_received"
+	MsgTrickWon        MessageType = "trick_won"
+	MsgHeartsBroken    MessageType = "hearts_broken"
+	MsgHandScored      MessageType = "hand_scored"
+	MsgHandStarted     MessageType = "hand_started"
+	MsgBetPlaced       MessageType = "bet_placed"
+	MsgCommunityDealt  MessageType = "community_dealt"
+	MsgShowdown        MessageType = "showdown"
+	MsgPotAwarded      MessageType = "pot_awarded"
+	MsgDealerPlayed    MessageType = "dealer_played"
+	MsgRoundSettled    MessageType = "round_settled"
+	MsgPairSung        MessageType = "pair_sung"
+	MsgCallMade        MessageType = "call_made"
+	MsgPointsScored    MessageType = "points_scored"
+	MsgKnocked         MessageType = "knocked"
+	MsgMeldLaid        MessageType = "meld_laid"
+	MsgCardsLaidOff    MessageType = "cards_laid_off"
+	MsgTurnTimeout     MessageType = "turn_timeout"
+	MsgTimeForfeit     MessageType = "time_forfeit"
+	MsgGamePaused      MessageType = "game_paused"
+	MsgGameResumed     MessageType = "game_resumed"
+	MsgRoundStarted    MessageType = "round_started"
+	MsgRoundScored     MessageType = "round_scored"
+	MsgMatchEnded      MessageType = "match_ended"
+	MsgAck             MessageType = "ack"
 	MsgError           MessageType = "error"
 )
 
 type WebSocketMessage struct {
 	Type    MessageType `json:"type"`
+	// RequestID is chosen by the client and sent back with the ack or error
+	// its message causes. Retried messages keep their ID so they are only
+	// applied once.
+	RequestID string    `json:"requestId,omitempty"`
+	// ExpectedVersion is the version of the game the client saw when it
+	// sent the message. Messages sent against a game that has changed since
+	// are rejected; zero expects no version.
+	ExpectedVersion int `json:"expectedVersion,omitempty"`
 	GameID  string      `json:"gameId,omitempty"`
 	PlayerID string     `json:"playerId,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
 // Game actions
 type JoinGameData struct {
 	PlayerName string `json:"playerName"`
 	GameID     string `json:"gameId,omitempty"`
+	RuleSet    string `json:"ruleSet,omitempty"`
+	// ClientSeed is mixed into the shuffles of provably fair games, which
+	// are created with ProvablyFair set
+	ClientSeed   string `json:"clientSeed,omitempty"`
+	ProvablyFair bool   `json:"provablyFair,omitempty"`
+	// SessionKey is kept by the client across reconnects, so a retried
+	// join is told apart from a new player taking a seat
+	SessionKey string `json:"sessionKey,omitempty"`
 }
 
+// CreateGameData creates a table with the given options and seats the
+// player creating it
+type CreateGameData struct {
+	PlayerName string      `json:"playerName"`
+	ClientSeed string      `json:"clientSeed,omitempty"`
+	SessionKey string      `json:"sessionKey,omitempty"`
+	Options    GameOptions `json:"options"`
+}
+
+// Card actions name the cards by their IDs, the server moves its own copy
+// of each card
 type PlayCardData struct {
-	Card Card `json:"card"`
+	CardID       string `json:"cardId"`
+	DeclaredSuit Suit   `json:"declaredSuit,omitempty"`
+}
+
+// DrawCardData chooses where a card is drawn from, "deck" or "discard",
+// the deck when empty
+type DrawCardData struct {
+	Source string `json:"source,omitempty"`
+}
+
+type DiscardCardData struct {
+	CardID string `json:"cardId"`
 }
 
 type GameStateData struct {
-	Game Game `json:"game"`
+	Game GameView `json:"game"`
 }
 
 type PlayerJoinedData struct {
-	Player Player `json:"player"`
+	Player PlayerView `json:"player"`
 }
 
 type PlayerLeftData struct {
@@ -103,9 +277,63 @@ type CardPlayedData struct {
 	Card     Card   `json:"card"`
 }
 
+type CardDrawnData struct {
+	PlayerID string `json:"playerId"`
+	// Card is only set when the card was taken from the discard pile
+	Card *Card `json:"card,omitempty"`
+}
+
+type CardDiscardedData struct {
+	PlayerID string `json:"playerId"`
+	Card     Card   `json:"card"`
+}
+
+type DeckReshuffledData struct {
+	Count     int `json:"count"`
+	DeckCount int `json:"deckCount"`
+}
+
+type SuitDeclaredData struct {
+	PlayerID string `json:"playerId"`
+	Suit     Suit   `json:"suit"`
+}
+
+type RaiseData struct {
+	Amount int `json:"amount"`
+}
+
+type PassCardsData struct {
+	CardIDs []string `json:"cardIds"`
+}
+
+type CardsPassedData struct {
+	PlayerID string `json:"playerId"`
+}
+
+type CardsReceivedData struct {
+	FromPlayerID string `json:"fromPlayerId"`
+	Cards        []Card `json:"cards"`
+}
+
+type HandScoredData struct {
+	Points      map[string]int `json:"points"`
+	ShotTheMoon string         `json:"shotTheMoon,omitempty"`
+}
+
+// DropCardsData moves a single card, or several laid down together as a
+// meld, to the shared zone
+type DropCardsData struct {
+	CardID   string   `json:"cardId,omitempty"`
+	CardIDs  []string `json:"cardIds,omitempty"`
+	MeldID   string   `json:"meldId,omitempty"`
+	Position Position `json:"position"`
+}
+
 type CardDroppedData struct {
 	PlayerID string  `json:"playerId"`
 	Card     Card    `json:"card"`
+	Cards    []Card  `json:"cards,omitempty"`
+	MeldID   string  `json:"meldId,omitempty"`
 	Position Position `json:"position"`
 }
 
@@ -114,8 +342,24 @@ type Position struct {
 	Y float64 `json:"y"`
 }
 
+type GamePausedData struct {
+	PlayerID string `json:"playerId"`
+	// PausesLeft is how many more times the player can pause the game
+	PausesLeft int `json:"pausesLeft,omitempty"`
+}
+
+// ErrorData tells a client why its message failed. Code identifies the
+// error and Key is the message clients show for it; Message is meant for
+// developers.
 type ErrorData struct {
-	Message string `json:"message"`
+	Code    ErrorCode `json:"code"`
+	Key     string    `json:"key"`
+	Message string    `json:"message"`
+	// Request and RequestID are the type and ID of the message that
+	// failed, and Field the part of its data that was missing or malformed
+	Request   MessageType `json:"request,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+	Field     string      `json:"field,omitempty"`
 }
 
 // NewCard creates a new card
@@ -136,42 +380,26 @@ func NewPlayer(name string) Player {
 		Hand:            make([]Card, 0),
 		Score:           0,
 		IsCurrentPlayer: false,
+		Connected:       true,
+		ResumeToken:     uuid.New().String(),
 		ConnectedAt:     time.Now(),
 	}
 }
 
-// NewGame creates a new game
-func NewGame() *Game {
+// NewGame creates a new game played with the named ruleset
+func NewGame(options GameOptions, seed int64) *Game {
 	return &Game{
 		ID:            uuid.New().String(),
+		RuleSet:       options.RuleSet,
+		Options:       options,
+		Seed:          seed,
 		Players:       make([]Player, 0),
 		CurrentPlayer: "",
 		Phase:         PhaseWaiting,
-		Deck:          createDeck(),
+		Deck:          tableDeck(options).Build(),
 		PlayedCards:   make([]Card, 0),
 		SharedZone:    make([]Card, 0),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
 }
-
-// createDeck creates a standard 52-card deck
-func createDeck() []Card {
-	suits := []Suit{Hearts, Diamonds, Clubs, Spades}
-	ranks := []struct {
-		rank  string
-		value int
-	}{
-		{"A", 1}, {"2", 2}, {"3", 3}, {"4", 4}, {"5", 5}, {"6", 6}, {"7", 7},
-		{"8", 8}, {"9", 9}, {"10", 10}, {"J", 11}, {"Q", 12}, {"K", 13},
-	}
-
-	deck := make([]Card, 0, 52)
-	for _, suit := range suits {
-		for _, rank := range ranks {
-			deck = append(deck, NewCard(suit, rank.rank, rank.value))
-		}
-	}
-
-	return deck
-}
\ No newline at end of file
@@ -0,0 +1,110 @@
+package game
+
+import "time"
+
+// PlayerView is a player as seen by a specific recipient. The hand is only
+// filled in for the recipient's own seat; opponents expose a card count.
+type PlayerView struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Hand            []Card `json:"hand"`
+	HandCount       int    `json:"handCount"`
+	Score           int    `json:"score"`
+	Chips           int    `json:"chips"`
+	IsCurrentPlayer bool   `json:"isCurrentPlayer"`
+	Connected       bool   `json:"connected"`
+	// TimeBank is the time left on the player's clock in milliseconds
+	TimeBank *int64 `json:"timeBank,omitempty"`
+}
+
+// GameView is the redacted game state sent to a single client
+type GameView struct {
+	ID            string        `json:"id"`
+	RuleSet       string        `json:"ruleSet"`
+	Options       GameOptions   `json:"options"`
+	Players       []PlayerView  `json:"players"`
+	CurrentPlayer string        `json:"currentPlayer"`
+	Phase         GamePhase     `json:"gamePhase"`
+	DeckCount     int           `json:"deckCount"`
+	PlayedCards   []Card        `json:"playedCards"`
+	SharedZone    []Card        `json:"sharedZone"`
+	Melds         []Meld        `json:"melds,omitempty"`
+	Match         *ScoreSheet   `json:"match,omitempty"`
+	RuleState     interface{}   `json:"ruleState,omitempty"`
+	Fairness      *FairnessView `json:"fairness,omitempty"`
+	TurnDeadline  *time.Time    `json:"turnDeadline,omitempty"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	Version       int           `json:"version"`
+}
+
+// NewPlayerView builds the view of a player for the given recipient
+func NewPlayerView(player Player, viewerID string) PlayerView {
+	view := PlayerView{
+		ID:              player.ID,
+		Name:            player.Name,
+		Hand:            make([]Card, 0),
+		HandCount:       len(player.Hand),
+		Score:           player.Score,
+		Chips:           player.Chips,
+		IsCurrentPlayer: player.IsCurrentPlayer,
+		Connected:       player.Connected,
+	}
+
+	if player.ID == viewerID {
+		view.Hand = append(view.Hand, player.Hand...)
+	}
+
+	return view
+}
+
+// NewGameView builds the state of a game as seen by the given player.
+// Other players' hands and the deck are never included.
+func NewGameView(game *Game, viewerID string) GameView {
+	players := make([]PlayerView, 0, len(game.Players))
+	for _, player := range game.Players {
+		playerView := NewPlayerView(player, viewerID)
+		if game.Options.TimeBank > 0 {
+			left := timeLeft(game, player).Milliseconds()
+			playerView.TimeBank = &left
+		}
+		players = append(players, playerView)
+	}
+
+	view := GameView{
+		ID:            game.ID,
+		RuleSet:       game.RuleSet,
+		Options:       game.Options,
+		Players:       players,
+		CurrentPlayer: game.CurrentPlayer,
+		Phase:         game.Phase,
+		DeckCount:     len(game.Deck),
+		PlayedCards:   append(make([]Card, 0, len(game.PlayedCards)), game.PlayedCards...),
+		SharedZone:    append(make([]Card, 0, len(game.SharedZone)), game.SharedZone...),
+		TurnDeadline:  game.TurnDeadline,
+		CreatedAt:     game.CreatedAt,
+		UpdatedAt:     game.UpdatedAt,
+		Version:       game.Version,
+	}
+
+	for _, meld := range game.Melds {
+		meld.Cards = append(make([]Card, 0, len(meld.Cards)), meld.Cards...)
+		view.Melds = append(view.Melds, meld)
+	}
+
+	if game.Match != nil {
+		sheet := scoreSheet(game)
+		view.Match = &sheet
+	}
+
+	// The server seed is revealed once the game has ended
+	if game.Fairness != nil {
+		view.Fairness = game.Fairness.View(game.Phase == PhaseFinished)
+	}
+
+	if viewer, ok := rulesFor(game).(StateViewer); ok {
+		view.RuleState = viewer.ViewState(game, viewerID)
+	}
+
+	return view
+}
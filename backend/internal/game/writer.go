@@ -0,0 +1,158 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// storeWriter writes games to the store in the background, so a slow store
+// never holds the manager's lock. Each game has at most one write in
+// flight and only its latest snapshot waits behind it, so a game's writes
+// reach the store in order.
+type storeWriter struct {
+	store  GameStore
+	mutex  sync.Mutex
+	idle   *sync.Cond
+	queued map[string]*queuedWrite
+	// failed holds the error of each game whose latest write failed
+	failed map[string]error
+}
+
+// queuedWrite is what waits to be written for a game. A game deleted and
+// then created again under the same ID is deleted before it is saved.
+type queuedWrite struct {
+	snapshot []byte
+	deleted  bool
+}
+
+func newStoreWriter(store GameStore) *storeWriter {
+	w := &storeWriter{
+		store:  store,
+		queued: make(map[string]*queuedWrite),
+		failed: make(map[string]error),
+	}
+	w.idle = sync.NewCond(&w.mutex)
+	return w
+}
+
+// save queues the snapshot of a game, replacing one not yet written
+func (w *storeWriter) save(gameID string, snapshot []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.queue(gameID).snapshot = snapshot
+}
+
+// delete queues the removal of a game, dropping its unwritten snapshot
+func (w *storeWriter) delete(gameID string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	queued := w.queue(gameID)
+	queued.snapshot = nil
+	queued.deleted = true
+}
+
+// queue returns what waits to be written for a game, starting its writer
+// when it has none. The lock must be held.
+func (w *storeWriter) queue(gameID string) *queuedWrite {
+	queued, exists := w.queued[gameID]
+	if !exists {
+		queued = &queuedWrite{}
+		w.queued[gameID] = queued
+		go w.run(gameID)
+	}
+	return queued
+}
+
+// run writes what is queued for a game until nothing is left
+func (w *storeWriter) run(gameID string) {
+	for {
+		w.mutex.Lock()
+		queued := w.queued[gameID]
+		if queued.snapshot == nil && !queued.deleted {
+			delete(w.queued, gameID)
+			w.idle.Broadcast()
+			w.mutex.Unlock()
+			return
+		}
+		write := *queued
+		*queued = queuedWrite{}
+		w.mutex.Unlock()
+
+		err := w.write(gameID, write)
+		if err != nil {
+			log.Printf("Error saving game %s: %v", gameID, err)
+		}
+
+		w.mutex.Lock()
+		if err != nil {
+			w.failed[gameID] = err
+		} else {
+			delete(w.failed, gameID)
+		}
+		w.mutex.Unlock()
+	}
+}
+
+func (w *storeWriter) write(gameID string, write queuedWrite) error {
+	if write.deleted {
+		if err := w.store.Delete(gameID); err != nil {
+			return err
+		}
+	}
+	if write.snapshot == nil {
+		return nil
+	}
+
+	var stored storedGame
+	if err := json.Unmarshal(write.snapshot, &stored); err != nil {
+		return err
+	}
+	return w.store.Save(stored.game())
+}
+
+// fail records the error of a game's change that could not be saved
+func (w *storeWriter) fail(gameID string, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.failed[gameID] = err
+}
+
+// flush waits until everything queued has been written
+func (w *storeWriter) flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for len(w.queued) > 0 {
+		w.idle.Wait()
+	}
+}
+
+// err returns the failure of a game whose latest write failed
+func (w *storeWriter) err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for gameID, err := range w.failed {
+		return fmt.Errorf("game %s: %w", gameID, err)
+	}
+	return nil
+}
+
+// FlushStore waits until every change made so far has been written to the
+// store
+func (gm *GameManager) FlushStore() {
+	gm.writer.flush()
+}
+
+// StoreError returns why a game could not be written to the store, or why
+// a change a timer made to it failed, nil when the latest write of every
+// game succeeded. A game that fails to save is written again with its next
+// change.
+func (gm *GameManager) StoreError() error {
+	return gm.writer.err()
+}
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"sync"
@@ -22,6 +23,38 @@ type Client struct {
 	hub      *Hub
 	gameID   string
 	playerID string
+	// sendMutex guards send against writes after it has been closed
+	sendMutex sync.Mutex
+	closed    bool
+}
+
+// trySend queues a message for the client without blocking. A client too
+// slow to keep up is closed, and the hub unregisters it once its
+// connection has ended. Messages to a closed client are dropped.
+func (c *Client) trySend(message []byte) {
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+
+	if c.closed {
+		return
+	}
+	select {
+	case c.send <- message:
+	default:
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// closeSend closes the client's queue, ending its write pump
+func (c *Client) closeSend() {
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
 }
 
 type Hub struct {
@@ -35,7 +68,7 @@ type Hub struct {
 }
 
 func NewHub(gameManager *game.GameManager) *Hub {
-	return &Hub{
+	h := &Hub{
 		clients:     make(map[*Client]bool),
 		gameClients: make(map[string]map[*Client]bool),
 		broadcast:   make(chan []byte, 256),
@@ -44,6 +77,8 @@ func NewHub(gameManager *game.GameManager) *Hub {
 		gameManager: gameManager,
 		mutex:       sync.RWMutex{},
 	}
+	gameManager.SetUpdateHandler(h.handleGameUpdate)
+	return h
 }
 
 func (h *Hub) Run() {
@@ -56,53 +91,80 @@ func (h *Hub) Run() {
 			log.Printf("Client connected: %p", client)
 
 		case client := <-h.unregister:
+			leaving := false
 			h.mutex.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
-				close(client.send)
+				client.closeSend()
 				
 				// Remove from game clients
 				if client.gameID != "" {
-					if gameClients, exists := h.gameClients[client.gameID]; exists {
-						delete(gameClients, client)
-						if len(gameClients) == 0 {
-							delete(h.gameClients, client.gameID)
-						}
-					}
-					
-					// Handle player leaving game
-					if client.playerID != "" {
-						h.handlePlayerLeave(client.gameID, client.playerID)
-					}
+					h.dropGameClient(client)
+					leaving = client.playerID != "" && !h.playerConnected(client.gameID, client.playerID)
 				}
 			}
 			h.mutex.Unlock()
+
+			// Hold the player's seat once the hub lock is released, since
+			// the broadcasts below take it again
+			if leaving {
+				h.handlePlayerDisconnect(client.gameID, client.playerID)
+			}
 			log.Printf("Client disconnected: %p", client)
 
 		case message := <-h.broadcast:
 			h.mutex.RLock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
+				client.trySend(message)
 			}
 			h.mutex.RUnlock()
 		}
 	}
 }
 
-func (h *Hub) addClientToGame(client *Client, gameID string) {
+// addClientToGame sends a game's messages to a client seated as the player.
+// The seat is set under the hub lock, as the broadcasts read it. A client
+// that was seated elsewhere stops getting that game's messages, and the
+// player it was seated as is held as disconnected.
+func (h *Hub) addClientToGame(client *Client, gameID, playerID string) {
 	h.mutex.Lock()
-	defer h.mutex.Unlock()
-	
+	previousGame, previousPlayer := client.gameID, client.playerID
+	h.dropGameClient(client)
+	moved := previousGame != gameID || previousPlayer != playerID
+	leaving := moved && previousPlayer != "" && !h.playerConnected(previousGame, previousPlayer)
+
 	if h.gameClients[gameID] == nil {
 		h.gameClients[gameID] = make(map[*Client]bool)
 	}
 	h.gameClients[gameID][client] = true
 	client.gameID = gameID
+	client.playerID = playerID
+	h.mutex.Unlock()
+
+	if leaving {
+		h.handlePlayerDisconnect(previousGame, previousPlayer)
+	}
+}
+
+// removeClientFromGame stops sending a game's messages to a client
+func (h *Hub) removeClientFromGame(client *Client) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.dropGameClient(client)
+	client.gameID = ""
+	client.playerID = ""
+}
+
+// dropGameClient takes a client out of the clients of its game. The hub
+// lock must be held.
+func (h *Hub) dropGameClient(client *Client) {
+	if gameClients, exists := h.gameClients[client.gameID]; exists {
+		delete(gameClients, client)
+		if len(gameClients) == 0 {
+			delete(h.gameClients, client.gameID)
+		}
+	}
 }
 
 func (h *Hub) broadcastToGame(gameID string, message []byte) {
@@ -111,17 +173,125 @@ func (h *Hub) broadcastToGame(gameID string, message []byte) {
 	
 	if gameClients, exists := h.gameClients[gameID]; exists {
 		for client := range gameClients {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				delete(h.clients, client)
-				delete(gameClients, client)
+			client.trySend(message)
+		}
+	}
+}
+
+// broadcastGameState sends each client in a game its own redacted view of
+// the game, so no client ever receives another player's hand
+func (h *Hub) broadcastGameState(gameID string, msgType game.MessageType) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if gameClients, exists := h.gameClients[gameID]; exists {
+		for client := range gameClients {
+			messageBytes, err := h.gameStateMessage(gameID, client.playerID, msgType)
+			if err != nil {
+				log.Printf("Error building game state: %v", err)
+				continue
 			}
+
+			client.trySend(messageBytes)
+		}
+	}
+}
+
+// broadcastEvents forwards the events queued by the game's ruleset, sending
+// private events only to the player they are meant for
+func (h *Hub) broadcastEvents(gameID string) {
+	for _, event := range h.gameManager.TakeEvents(gameID) {
+		messageBytes, err := marshalMessage(event.Type, "", event.Data)
+		if err != nil {
+			continue
+		}
+
+		if event.PlayerID == "" {
+			h.broadcastToGame(gameID, messageBytes)
+		} else {
+			h.sendToPlayer(gameID, event.PlayerID, messageBytes)
 		}
 	}
 }
 
+// handleGameUpdate updates the clients of a game after the game manager
+// changed it on its own, such as a move made for a player who ran out of
+// time
+func (h *Hub) handleGameUpdate(gameID string, finished bool) {
+	h.broadcastEvents(gameID)
+	h.broadcastGameState(gameID, game.MsgGameState)
+
+	if finished {
+		h.broadcastGameState(gameID, game.MsgGameEnded)
+	}
+}
+
+// sendToPlayer sends a message to the clients of a single player in a game
+func (h *Hub) sendToPlayer(gameID, playerID string, message []byte) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if gameClients, exists := h.gameClients[gameID]; exists {
+		for client := range gameClients {
+			if client.playerID != playerID {
+				continue
+			}
+
+			client.trySend(message)
+		}
+	}
+}
+
+// gameStateMessage marshals the view of a game for a single player
+func (h *Hub) gameStateMessage(gameID, playerID string, msgType game.MessageType) ([]byte, error) {
+	view, err := h.gameManager.GameView(gameID, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalMessage(msgType, "", game.GameStateData{Game: view})
+}
+
+// marshalMessage encodes a message for clients with its data
+func marshalMessage(msgType game.MessageType, gameID string, data interface{}) ([]byte, error) {
+	message := game.WebSocketMessage{
+		Type:   msgType,
+		GameID: gameID,
+	}
+
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		message.Data = raw
+	}
+	return json.Marshal(message)
+}
+
+// playerConnected reports whether a player still has a client in the game,
+// as after resuming on a new connection. The hub lock must be held.
+func (h *Hub) playerConnected(gameID, playerID string) bool {
+	for client := range h.gameClients[gameID] {
+		if client.playerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// handlePlayerDisconnect holds the seat of a player whose connection
+// dropped, so they can resume within the grace period
+func (h *Hub) handlePlayerDisconnect(gameID, playerID string) {
+	if _, err := h.gameManager.DisconnectPlayer(gameID, playerID); err != nil {
+		log.Printf("Error handling player disconnect: %v", err)
+		return
+	}
+
+	h.broadcastEvents(gameID)
+	h.broadcastGameState(gameID, game.MsgGameState)
+}
+
 func (h *Hub) handlePlayerLeave(gameID, playerID string) {
 	updatedGame, err := h.gameManager.LeaveGame(gameID, playerID)
 	if err != nil {
@@ -131,24 +301,16 @@ func (h *Hub) handlePlayerLeave(gameID, playerID string) {
 
 	if updatedGame != nil {
 		// Broadcast player left message
-		leftMessage := game.WebSocketMessage{
-			Type: game.MsgPlayerLeft,
-			Data: game.PlayerLeftData{PlayerID: playerID},
-		}
-		
-		if messageBytes, err := json.Marshal(leftMessage); err == nil {
+		leftData := game.PlayerLeftData{PlayerID: playerID}
+		if messageBytes, err := marshalMessage(game.MsgPlayerLeft, "", leftData); err == nil {
 			h.broadcastToGame(gameID, messageBytes)
 		}
 
+		// Their hand may have been folded or forfeited
+		h.broadcastEvents(gameID)
+
 		// Broadcast updated game state
-		stateMessage := game.WebSocketMessage{
-			Type: game.MsgGameState,
-			Data: game.GameStateData{Game: *updatedGame},
-		}
-		
-		if messageBytes, err := json.Marshal(stateMessage); err == nil {
-			h.broadcastToGame(gameID, messageBytes)
-		}
+		h.broadcastGameState(gameID, game.MsgGameState)
 	}
 }
 
@@ -187,6 +349,7 @@ func (c *Client) readPump() {
 		var message game.WebSocketMessage
 		if err := json.Unmarshal(messageBytes, &message); err != nil {
 			log.Printf("JSON unmarshal error: %v", err)
+			c.sendError(message, errInvalidMessage)
 			continue
 		}
 
@@ -213,245 +376,578 @@ func (c *Client) writePump() {
 	}
 }
 
-func (c *Client) handleMessage(message game.WebSocketMessage) {
-	switch message.Type {
-	case game.MsgJoinGame:
-		c.handleJoinGame(message)
-	case game.MsgPlayCard:
-		c.handlePlayCard(message)
-	case game.MsgDealCards:
-		c.handleDealCards(message)
-	case game.MsgDropCardShared:
-		c.handleDropCardShared(message)
-	default:
-		log.Printf("Unknown message type: %s", message.Type)
+// handler handles a message sent by a client, returning why it failed
+type handler func(c *Client, message game.WebSocketMessage) error
+
+// withPayload decodes the data of a message into its payload type and
+// validates it before handling the message, failing when the data is
+// malformed
+func withPayload[T any](handle func(c *Client, message game.WebSocketMessage, data *T) error) handler {
+	return func(c *Client, message game.WebSocketMessage) error {
+		var data T
+		if err := game.DecodePayload(message.Data, &data); err != nil {
+			return err
+		}
+		return handle(c, message, &data)
 	}
 }
 
-func (c *Client) handleJoinGame(message game.WebSocketMessage) {
-	data, ok := message.Data.(map[string]interface{})
+// handlers maps each message type clients may send to its handler and, for
+// messages carrying data, the payload type it is decoded into
+var handlers = map[game.MessageType]handler{
+	game.MsgCreateGame:     withPayload((*Client).handleCreateGame),
+	game.MsgJoinGame:       withPayload((*Client).handleJoinGame),
+	game.MsgLeaveGame:      (*Client).handleLeaveGame,
+	game.MsgResumeSession:  withPayload((*Client).handleResumeSession),
+	game.MsgPauseGame:      (*Client).handlePause,
+	game.MsgResumeGame:     (*Client).handlePause,
+	game.MsgPlayCard:       withPayload((*Client).handlePlayCard),
+	game.MsgDealCards:      (*Client).handleDealCards,
+	game.MsgDropCardShared: withPayload((*Client).handleDropCardShared),
+	game.MsgDrawCard:       withPayload((*Client).handleDrawCard),
+	game.MsgDiscardCard:    withPayload((*Client).handleDiscardCard),
+	game.MsgPassCards:      withPayload((*Client).handlePassCards),
+	game.MsgFold:           (*Client).handleBet,
+	game.MsgCheck:          (*Client).handleBet,
+	game.MsgCall:           (*Client).handleBet,
+	game.MsgRaise:          withPayload((*Client).handleRaise),
+	game.MsgAllIn:          (*Client).handleBet,
+	game.MsgPlaceBet:       withPayload((*Client).handlePlaceBet),
+	game.MsgHit:            (*Client).handleHandAction,
+	game.MsgStand:          (*Client).handleHandAction,
+	game.MsgDouble:         (*Client).handleHandAction,
+	game.MsgSplit:          (*Client).handleHandAction,
+	game.MsgKnock:          withPayload((*Client).handleKnock),
+	game.MsgEndTurn:        (*Client).handleEndTurn,
+	game.MsgSing:           withPayload((*Client).handleSing),
+	game.MsgEnvido:         (*Client).handleCall,
+	game.MsgRealEnvido:     (*Client).handleCall,
+	game.MsgFaltaEnvido:    (*Client).handleCall,
+	game.MsgTruco:          (*Client).handleCall,
+	game.MsgRetruco:        (*Client).handleCall,
+	game.MsgValeCuatro:     (*Client).handleCall,
+	game.MsgQuiero:         (*Client).handleCall,
+	game.MsgNoQuiero:       (*Client).handleCall,
+}
+
+func (c *Client) handleMessage(message game.WebSocketMessage) {
+	handle, ok := handlers[message.Type]
 	if !ok {
-		c.sendError("Invalid join game data")
+		log.Printf("Unknown message type: %s", message.Type)
+		c.sendError(message, errUnknownMessage)
 		return
 	}
 
-	playerName, ok := data["playerName"].(string)
-	if !ok || playerName == "" {
-		c.sendError("Player name is required")
-		return
+	err := handle(c, message)
+	switch {
+	case errors.Is(err, game.ErrDuplicateRequest):
+		// A message retried with the ID of one already handled is
+		// acknowledged again without being applied twice
+		c.sendAck(message, true)
+	case err != nil:
+		c.sendError(message, err)
+		// Moves made against a stale view are rejected, and the client is
+		// sent the table as it is now
+		if errors.Is(err, game.ErrStaleVersion) {
+			if messageBytes, err := c.hub.gameStateMessage(c.gameID, c.playerID, game.MsgGameState); err == nil {
+				c.trySend(messageBytes)
+			}
+		}
+	case message.RequestID != "":
+		c.sendAck(message, false)
+	}
+}
+
+// request names the game and player a message from the client is for,
+// along with the version of the game the client saw
+func (c *Client) request(message game.WebSocketMessage) game.Request {
+	return game.Request{
+		GameID:          c.gameID,
+		PlayerID:        c.playerID,
+		ID:              message.RequestID,
+		ExpectedVersion: message.ExpectedVersion,
 	}
+}
 
+func (c *Client) handleJoinGame(message game.WebSocketMessage, data *game.JoinGameData) error {
+	// The game may be named on the message or in its data
 	gameID := message.GameID
 	if gameID == "" {
-		// Create new game if no game ID provided
-		newGame := c.hub.gameManager.CreateGame()
-		gameID = newGame.ID
+		gameID = data.GameID
+	}
+
+	seat := game.SeatRequest{SessionKey: data.SessionKey, ID: message.RequestID}
+	if gameID == "" {
+		// Create new game if no game ID provided. Ruleset is optional and
+		// only used when a new game is created.
+		options := game.GameOptions{RuleSet: data.RuleSet, ProvablyFair: data.ProvablyFair}
+		updatedGame, player, err := c.hub.gameManager.HostGame(options, data.PlayerName, data.ClientSeed, seat)
+		return c.takeSeat(updatedGame, player, err)
 	}
 
-	updatedGame, player, err := c.hub.gameManager.JoinGame(gameID, playerName)
+	updatedGame, player, err := c.hub.gameManager.JoinGame(gameID, data.PlayerName, data.RuleSet, data.ClientSeed, seat)
+	return c.takeSeat(updatedGame, player, err)
+}
+
+func (c *Client) handleCreateGame(message game.WebSocketMessage, data *game.CreateGameData) error {
+	// A retried request joins the game the first one created
+	seat := game.SeatRequest{SessionKey: data.SessionKey, ID: message.RequestID}
+	updatedGame, player, err := c.hub.gameManager.HostGame(data.Options, data.PlayerName, data.ClientSeed, seat)
+	return c.takeSeat(updatedGame, player, err)
+}
+
+// takeSeat seats the client as the player the manager seated, sends them
+// the table and tells the other players. A retried request gives the
+// client back the seat the first one took.
+func (c *Client) takeSeat(updatedGame *game.Game, player *game.Player, err error) error {
+	if errors.Is(err, game.ErrDuplicateRequest) {
+		if err := c.resumeSeat(updatedGame.ID, player.ResumeToken); err != nil {
+			return err
+		}
+		return game.ErrDuplicateRequest
+	}
 	if err != nil {
-		c.sendError(err.Error())
-		return
+		return err
 	}
 
-	c.playerID = player.ID
-	c.hub.addClientToGame(c, gameID)
+	gameID := updatedGame.ID
+	c.hub.addClientToGame(c, gameID, player.ID)
+	c.sendSession(gameID, *player)
 
 	// Send game state to new player
-	stateMessage := game.WebSocketMessage{
-		Type: game.MsgGameState,
-		Data: game.GameStateData{Game: *updatedGame},
-	}
-	
-	if messageBytes, err := json.Marshal(stateMessage); err == nil {
-		c.send <- messageBytes
+	if messageBytes, err := c.hub.gameStateMessage(gameID, c.playerID, game.MsgGameState); err == nil {
+		c.trySend(messageBytes)
 	}
 
 	// Broadcast player joined to other players
-	joinedMessage := game.WebSocketMessage{
-		Type: game.MsgPlayerJoined,
-		Data: game.PlayerJoinedData{Player: *player},
-	}
-	
-	if messageBytes, err := json.Marshal(joinedMessage); err == nil {
+	joinedData := game.PlayerJoinedData{Player: game.NewPlayerView(*player, "")}
+	if messageBytes, err := marshalMessage(game.MsgPlayerJoined, "", joinedData); err == nil {
 		c.hub.broadcastToGame(gameID, messageBytes)
 	}
+	return nil
 }
 
-func (c *Client) handlePlayCard(message game.WebSocketMessage) {
-	if c.gameID == "" || c.playerID == "" {
-		c.sendError("Not in a game")
-		return
+// sendSession gives the client the token to resume its seat with
+func (c *Client) sendSession(gameID string, player game.Player) {
+	sessionData := game.SessionData{
+		GameID:      gameID,
+		PlayerID:    player.ID,
+		ResumeToken: player.ResumeToken,
 	}
 
-	data, ok := message.Data.(map[string]interface{})
-	if !ok {
-		c.sendError("Invalid play card data")
-		return
+	if messageBytes, err := marshalMessage(game.MsgSession, gameID, sessionData); err == nil {
+		c.trySend(messageBytes)
 	}
+}
 
-	cardData, ok := data["card"].(map[string]interface{})
-	if !ok {
-		c.sendError("Card data is required")
-		return
+func (c *Client) handleResumeSession(message game.WebSocketMessage, data *game.ResumeSessionData) error {
+	if message.GameID == "" {
+		return &game.PayloadError{Field: "gameId", Message: "is required"}
 	}
 
-	card := game.Card{
-		ID:    cardData["id"].(string),
-		Suit:  game.Suit(cardData["suit"].(string)),
-		Rank:  cardData["rank"].(string),
-		Value: int(cardData["value"].(float64)),
+	return c.resumeSeat(message.GameID, data.ResumeToken)
+}
+
+// resumeSeat seats the client back at a game with a player's resume token
+func (c *Client) resumeSeat(gameID, resumeToken string) error {
+	_, player, err := c.hub.gameManager.ResumeSession(gameID, resumeToken)
+	if err != nil {
+		return err
 	}
 
-	updatedGame, err := c.hub.gameManager.PlayCard(c.gameID, c.playerID, card)
+	c.hub.addClientToGame(c, gameID, player.ID)
+	c.sendSession(gameID, *player)
+
+	// Tell everyone the player is back, resuming play if it was waiting
+	c.hub.broadcastEvents(gameID)
+	c.hub.broadcastGameState(gameID, game.MsgGameState)
+	return nil
+}
+
+func (c *Client) handleLeaveGame(message game.WebSocketMessage) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	gameID, playerID := c.gameID, c.playerID
+	c.hub.removeClientFromGame(c)
+	c.hub.handlePlayerLeave(gameID, playerID)
+	return nil
+}
+
+func (c *Client) handlePlayCard(message game.WebSocketMessage, data *game.PlayCardData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	// Declared suit is only needed for wild cards
+	updatedGame, err := c.hub.gameManager.PlayCard(c.request(message), data.CardID, data.DeclaredSuit)
 	if err != nil {
-		c.sendError(err.Error())
-		return
+		return err
 	}
 
-	// Broadcast card played
-	playedMessage := game.WebSocketMessage{
-		Type: game.MsgCardPlayed,
-		Data: game.CardPlayedData{
-			PlayerID: c.playerID,
-			Card:     card,
-		},
+	// Broadcast card played and what follows from it
+	c.hub.broadcastEvents(c.gameID)
+
+	// Broadcast updated game state
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
-	
-	if messageBytes, err := json.Marshal(playedMessage); err == nil {
-		c.hub.broadcastToGame(c.gameID, messageBytes)
+	return nil
+}
+
+func (c *Client) handleDealCards(message game.WebSocketMessage) error {
+	if c.gameID == "" {
+		return errNotInGame
+	}
+
+	updatedGame, err := c.hub.gameManager.DealCards(c.request(message))
+	if err != nil {
+		return err
 	}
 
+	// Broadcast cards dealt message
+	c.hub.broadcastGameState(c.gameID, game.MsgCardsDealt)
+	c.hub.broadcastEvents(c.gameID)
+
 	// Broadcast updated game state
-	stateMessage := game.WebSocketMessage{
-		Type: game.MsgGameState,
-		Data: game.GameStateData{Game: *updatedGame},
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
-	
-	if messageBytes, err := json.Marshal(stateMessage); err == nil {
-		c.hub.broadcastToGame(c.gameID, messageBytes)
+	return nil
+}
+
+func (c *Client) handleDrawCard(message game.WebSocketMessage, data *game.DrawCardData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	// Cards come from the deck unless the discard pile is asked for
+	fromDiscard := data.Source == "discard"
+
+	if _, err := c.hub.gameManager.DrawCard(c.request(message), fromDiscard); err != nil {
+		return err
 	}
 
+	// Broadcast card drawn, any reshuffle and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+	return nil
+}
+
+func (c *Client) handleDiscardCard(message game.WebSocketMessage, data *game.DiscardCardData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	updatedGame, err := c.hub.gameManager.DiscardCard(c.request(message), data.CardID)
+	if err != nil {
+		return err
+	}
+
+	// Broadcast card discarded and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
 	// Check if game ended
 	if updatedGame.Phase == game.PhaseFinished {
-		endMessage := game.WebSocketMessage{
-			Type: game.MsgGameEnded,
-			Data: game.GameStateData{Game: *updatedGame},
-		}
-		
-		if messageBytes, err := json.Marshal(endMessage); err == nil {
-			c.hub.broadcastToGame(c.gameID, messageBytes)
-		}
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
+	return nil
 }
 
-func (c *Client) handleDealCards(message game.WebSocketMessage) {
-	if c.gameID == "" {
-		c.sendError("Not in a game")
-		return
+func (c *Client) handlePassCards(message game.WebSocketMessage, data *game.PassCardsData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	// Only the card IDs matter, the server knows the player's hand
+	if _, err := c.hub.gameManager.PassCards(c.request(message), data.CardIDs); err != nil {
+		return err
+	}
+
+	// Broadcast passes, received cards and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+	return nil
+}
+
+func (c *Client) handleBet(message game.WebSocketMessage) error {
+	return c.bet(message, 0)
+}
+
+// handleRaise raises the bet to the total amount given
+func (c *Client) handleRaise(message game.WebSocketMessage, data *game.RaiseData) error {
+	return c.bet(message, data.Amount)
+}
+
+// bet makes a betting action, with an amount only for raises
+func (c *Client) bet(message game.WebSocketMessage, amount int) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
 	}
 
-	updatedGame, err := c.hub.gameManager.DealCards(c.gameID)
+	updatedGame, err := c.hub.gameManager.Bet(c.request(message), game.BetAction(message.Type), amount)
 	if err != nil {
-		c.sendError(err.Error())
-		return
+		return err
 	}
 
-	// Broadcast cards dealt message
-	dealtMessage := game.WebSocketMessage{
-		Type: game.MsgCardsDealt,
-		Data: game.GameStateData{Game: *updatedGame},
+	// Broadcast betting events and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
-	
-	if messageBytes, err := json.Marshal(dealtMessage); err == nil {
-		c.hub.broadcastToGame(c.gameID, messageBytes)
+	return nil
+}
+
+func (c *Client) handlePlaceBet(message game.WebSocketMessage, data *game.PlaceBetData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	if _, err := c.hub.gameManager.PlaceBet(c.request(message), data.Amount); err != nil {
+		return err
 	}
 
 	// Broadcast updated game state
-	stateMessage := game.WebSocketMessage{
-		Type: game.MsgGameState,
-		Data: game.GameStateData{Game: *updatedGame},
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+	return nil
+}
+
+func (c *Client) handleHandAction(message game.WebSocketMessage) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
 	}
-	
-	if messageBytes, err := json.Marshal(stateMessage); err == nil {
-		c.hub.broadcastToGame(c.gameID, messageBytes)
+
+	updatedGame, err := c.hub.gameManager.HandAction(c.request(message), game.HandAction(message.Type))
+	if err != nil {
+		return err
+	}
+
+	// Broadcast dealer and settlement events and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
+	return nil
 }
 
-func (c *Client) handleDropCardShared(message game.WebSocketMessage) {
+func (c *Client) handleSing(message game.WebSocketMessage, data *game.SingData) error {
 	if c.gameID == "" || c.playerID == "" {
-		c.sendError("Not in a game")
-		return
+		return errNotInGame
 	}
 
-	data, ok := message.Data.(map[string]interface{})
-	if !ok {
-		c.sendError("Invalid drop card data")
-		return
+	// An empty suit declares all four kings or horses
+	updatedGame, err := c.hub.gameManager.Sing(c.request(message), data.Suit)
+	if err != nil {
+		return err
 	}
 
-	cardData, ok := data["card"].(map[string]interface{})
-	if !ok {
-		c.sendError("Card data is required")
-		return
+	// Broadcast the pair sung and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
+	return nil
+}
 
-	positionData, ok := data["position"].(map[string]interface{})
-	if !ok {
-		c.sendError("Position data is required")
-		return
+func (c *Client) handleCall(message game.WebSocketMessage) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
 	}
 
-	card := game.Card{
-		ID:    cardData["id"].(string),
-		Suit:  game.Suit(cardData["suit"].(string)),
-		Rank:  cardData["rank"].(string),
-		Value: int(cardData["value"].(float64)),
+	updatedGame, err := c.hub.gameManager.Call(c.request(message), game.TrucoCall(message.Type))
+	if err != nil {
+		return err
 	}
 
-	position := game.Position{
-		X: positionData["x"].(float64),
-		Y: positionData["y"].(float64),
+	// Broadcast the call and any points scored, then updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
 	}
+	return nil
+}
 
-	updatedGame, err := c.hub.gameManager.DropCardInSharedZone(c.gameID, c.playerID, card, position)
+func (c *Client) handleDropCardShared(message game.WebSocketMessage, data *game.DropCardsData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	// Cards are laid off onto a meld when one is given
+	_, err := c.hub.gameManager.DropCardInSharedZone(c.request(message), data.Cards(), data.Position, data.MeldID)
 	if err != nil {
-		c.sendError(err.Error())
-		return
+		return err
 	}
 
-	// Broadcast card dropped
-	droppedMessage := game.WebSocketMessage{
-		Type: game.MsgCardDropped,
-		Data: game.CardDroppedData{
-			PlayerID: c.playerID,
-			Card:     card,
-			Position: position,
-		},
+	// Broadcast cards dropped and any melds laid
+	c.hub.broadcastEvents(c.gameID)
+
+	// Broadcast updated game state
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+	return nil
+}
+
+func (c *Client) handleKnock(message game.WebSocketMessage, data *game.KnockData) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
 	}
-	
-	if messageBytes, err := json.Marshal(droppedMessage); err == nil {
-		c.hub.broadcastToGame(c.gameID, messageBytes)
+
+	if _, err := c.hub.gameManager.Knock(c.request(message), data.CardID); err != nil {
+		return err
 	}
 
-	// Broadcast updated game state
-	stateMessage := game.WebSocketMessage{
-		Type: game.MsgGameState,
-		Data: game.GameStateData{Game: *updatedGame},
+	// Broadcast the knock, the knocker's melds and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+	return nil
+}
+
+func (c *Client) handleEndTurn(message game.WebSocketMessage) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
 	}
-	
-	if messageBytes, err := json.Marshal(stateMessage); err == nil {
-		c.hub.broadcastToGame(c.gameID, messageBytes)
+
+	updatedGame, err := c.hub.gameManager.EndTurn(c.request(message))
+	if err != nil {
+		return err
+	}
+
+	// Broadcast scoring and updated game state
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+
+	// Check if game ended
+	if updatedGame.Phase == game.PhaseFinished {
+		c.hub.broadcastGameState(c.gameID, game.MsgGameEnded)
+	}
+	return nil
+}
+
+// Errors of the hub itself
+var (
+	errInvalidMessage = errors.New("invalid message")
+	errUnknownMessage = errors.New("unknown message type")
+	errNotInGame      = errors.New("not in a game")
+)
+
+// errorCodes maps the errors sent to clients to their codes. Errors that
+// are not listed are failures of the server.
+var errorCodes = map[error]game.ErrorCode{
+	errInvalidMessage:          game.CodeInvalidMessage,
+	errUnknownMessage:          game.CodeUnknownMessage,
+	errNotInGame:               game.CodeNotInGame,
+	game.ErrGameNotFound:       game.CodeGameNotFound,
+	game.ErrPlayerNotFound:     game.CodePlayerNotFound,
+	game.ErrUnknownRuleSet:     game.CodeUnknownRuleSet,
+	game.ErrInvalidOptions:     game.CodeInvalidOptions,
+	game.ErrGameFull:           game.CodeGameFull,
+	game.ErrNameTaken:          game.CodeNameTaken,
+	game.ErrInvalidResumeToken: game.CodeInvalidResumeToken,
+	game.ErrNotEnoughPlayers:   game.CodeNotEnoughPlayers,
+	game.ErrNotPlaying:         game.CodeWrongPhase,
+	game.ErrNotPassing:         game.CodeWrongPhase,
+	game.ErrGamePaused:         game.CodeGamePaused,
+	game.ErrGameNotPaused:      game.CodeWrongPhase,
+	game.ErrPlayerDisconnected: game.CodePlayerDisconnected,
+	game.ErrNotYourTurn:        game.CodeNotYourTurn,
+	game.ErrStaleVersion:       game.CodeStaleVersion,
+	game.ErrCardNotInHand:      game.CodeCardNotInHand,
+	game.ErrActionNotAllowed:   game.CodeActionNotAllowed,
+	game.ErrCardsMismatch:      game.CodeInternalError,
+	game.ErrRoundInProgress:    game.CodeRoundInProgress,
+	game.ErrNotDealt:           game.CodeWrongPhase,
+	game.ErrDeckEmpty:          game.CodeDeckEmpty,
+	game.ErrIllegalCard:        game.CodeIllegalCard,
+	game.ErrNotEnoughChips:     game.CodeNotEnoughChips,
+	game.ErrInvalidBet:         game.CodeInvalidBet,
+	game.ErrInvalidMeld:        game.CodeInvalidMeld,
+	game.ErrIllegalMove:        game.CodeInvalidMove,
+}
+
+// errorCode finds the code of an error sent to a client
+func errorCode(err error) game.ErrorCode {
+	var payloadErr *game.PayloadError
+	if errors.As(err, &payloadErr) {
+		return game.CodeInvalidPayload
 	}
+
+	for target, code := range errorCodes {
+		if errors.Is(err, target) {
+			return code
+		}
+	}
+	return game.CodeInternalError
 }
 
-func (c *Client) sendError(message string) {
-	errorMessage := game.WebSocketMessage{
-		Type: game.MsgError,
-		Data: game.ErrorData{Message: message},
+// sendAck tells the client a message it sent with a request ID was handled
+func (c *Client) sendAck(message game.WebSocketMessage, duplicate bool) {
+	ackData := game.AckData{
+		Request:   message.Type,
+		RequestID: message.RequestID,
+		Duplicate: duplicate,
 	}
-	
-	if messageBytes, err := json.Marshal(errorMessage); err == nil {
-		c.send <- messageBytes
+
+	if messageBytes, err := marshalMessage(game.MsgAck, "", ackData); err == nil {
+		c.trySend(messageBytes)
 	}
-}
\ No newline at end of file
+}
+
+// sendError tells the client why a message it sent failed
+func (c *Client) sendError(message game.WebSocketMessage, err error) {
+	code := errorCode(err)
+	errorData := game.ErrorData{
+		Code:      code,
+		Key:       code.Key(),
+		Message:   err.Error(),
+		Request:   message.Type,
+		RequestID: message.RequestID,
+	}
+
+	var payloadErr *game.PayloadError
+	if errors.As(err, &payloadErr) {
+		errorData.Field = payloadErr.Field
+	}
+
+	if messageBytes, err := marshalMessage(game.MsgError, "", errorData); err == nil {
+		c.trySend(messageBytes)
+	}
+}
+
+func (c *Client) handlePause(message game.WebSocketMessage) error {
+	if c.gameID == "" || c.playerID == "" {
+		return errNotInGame
+	}
+
+	var err error
+	if message.Type == game.MsgPauseGame {
+		_, err = c.hub.gameManager.PauseGame(c.request(message))
+	} else {
+		_, err = c.hub.gameManager.ResumeGame(c.request(message))
+	}
+	if err != nil {
+		return err
+	}
+
+	c.hub.broadcastEvents(c.gameID)
+	c.hub.broadcastGameState(c.gameID, game.MsgGameState)
+	return nil
+}
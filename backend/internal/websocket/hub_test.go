@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"card-game-backend/internal/game"
+)
+
+// newTestClient registers a client with a queue but no connection
+func newTestClient(hub *Hub) *Client {
+	client := &Client{send: make(chan []byte, 256), hub: hub}
+	hub.clients[client] = true
+	return client
+}
+
+func TestTrySend(t *testing.T) {
+	client := &Client{send: make(chan []byte, 1)}
+
+	client.trySend([]byte("first"))
+	if client.closed {
+		t.Fatal("client closed with room in its queue")
+	}
+
+	// A full queue closes the client, and later messages are dropped
+	client.trySend([]byte("second"))
+	if !client.closed {
+		t.Fatal("slow client left open")
+	}
+	client.trySend([]byte("third"))
+	client.closeSend()
+
+	if message := <-client.send; string(message) != "first" {
+		t.Fatalf("got %q, want the first message", message)
+	}
+	if _, ok := <-client.send; ok {
+		t.Fatal("queue not closed after the first message")
+	}
+}
+
+func TestAddClientToAnotherGame(t *testing.T) {
+	gm := game.NewGameManager(nil)
+	hub := NewHub(gm)
+	client := newTestClient(hub)
+
+	first, firstPlayer, err := gm.HostGame(game.GameOptions{}, "alice", "", game.SeatRequest{})
+	if err != nil {
+		t.Fatalf("hosting the first game: %v", err)
+	}
+	second, secondPlayer, err := gm.HostGame(game.GameOptions{}, "alice", "", game.SeatRequest{})
+	if err != nil {
+		t.Fatalf("hosting the second game: %v", err)
+	}
+
+	hub.addClientToGame(client, first.ID, firstPlayer.ID)
+	hub.addClientToGame(client, second.ID, secondPlayer.ID)
+
+	if hub.gameClients[first.ID][client] || !hub.gameClients[second.ID][client] {
+		t.Fatal("client still gets the first game's messages")
+	}
+	if first.Players[0].Connected {
+		t.Fatal("seat in the first game not held as disconnected")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want game.ErrorCode
+	}{
+		{"round in progress", fmt.Errorf("dealing: %w", game.ErrRoundInProgress), game.CodeRoundInProgress},
+		{"not dealt", fmt.Errorf("no hand: %w", game.ErrNotDealt), game.CodeWrongPhase},
+		{"deck empty", fmt.Errorf("drawing: %w", game.ErrDeckEmpty), game.CodeDeckEmpty},
+		{"illegal card", fmt.Errorf("must follow suit: %w", game.ErrIllegalCard), game.CodeIllegalCard},
+		{"not enough chips", fmt.Errorf("raising: %w", game.ErrNotEnoughChips), game.CodeNotEnoughChips},
+		{"invalid bet", fmt.Errorf("raising: %w", game.ErrInvalidBet), game.CodeInvalidBet},
+		{"invalid meld", fmt.Errorf("laying down: %w", game.ErrInvalidMeld), game.CodeInvalidMeld},
+		{"illegal move", fmt.Errorf("knocking: %w", game.ErrIllegalMove), game.CodeInvalidMove},
+		{"action not allowed", game.ErrActionNotAllowed, game.CodeActionNotAllowed},
+		{"stale version", game.ErrStaleVersion, game.CodeStaleVersion},
+		{"cards mismatch", game.ErrCardsMismatch, game.CodeInternalError},
+		{"payload", &game.PayloadError{Field: "cardId", Message: "is required"}, game.CodeInvalidPayload},
+		{"not in a game", errNotInGame, game.CodeNotInGame},
+		{"unknown failure", errors.New("database is down"), game.CodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCode(tt.err); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestErrorCodesCoverKinds checks that the rejections rulesets make reach
+// clients with their own code
+func TestErrorCodesCoverKinds(t *testing.T) {
+	gm := game.NewGameManager(nil)
+	table, _, err := gm.HostGame(game.GameOptions{RuleSet: "hearts"}, "alice", "", game.SeatRequest{})
+	if err != nil {
+		t.Fatalf("hosting: %v", err)
+	}
+	for _, name := range []string{"bob", "carol", "dave"} {
+		if _, _, err := gm.JoinGame(table.ID, name, "", "", game.SeatRequest{}); err != nil {
+			t.Fatalf("joining: %v", err)
+		}
+	}
+	request := game.Request{GameID: table.ID, PlayerID: table.Players[0].ID}
+	if _, err := gm.DealCards(request); err != nil {
+		t.Fatalf("dealing: %v", err)
+	}
+
+	_, err = gm.DealCards(request)
+	if got := errorCode(err); got != game.CodeRoundInProgress {
+		t.Fatalf("redeal: got %s, want %s", got, game.CodeRoundInProgress)
+	}
+	_, err = gm.DropCardInSharedZone(request, []string{table.Players[0].Hand[0].ID}, game.Position{}, "")
+	if got := errorCode(err); got != game.CodeActionNotAllowed {
+		t.Fatalf("drop: got %s, want %s", got, game.CodeActionNotAllowed)
+	}
+}
+
+func TestBadPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		msgType   game.MessageType
+		data      string
+		wantField string
+	}{
+		{"data of the wrong type", game.MsgJoinGame, `{"playerName": 5}`, "playerName"},
+		{"malformed data", game.MsgPlayCard, `{"cardId": `, ""},
+		{"missing card", game.MsgPlayCard, `{}`, "cardId"},
+		{"missing name", game.MsgCreateGame, `{"options": {}}`, "playerName"},
+		{"long name", game.MsgCreateGame, `{"playerName": "` + strings.Repeat("x", 101) + `"}`, "playerName"},
+		{"unknown draw source", game.MsgDrawCard, `{"source": "hand"}`, "source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm := game.NewGameManager(nil)
+			client := newTestClient(NewHub(gm))
+
+			client.handleMessage(game.WebSocketMessage{Type: tt.msgType, RequestID: "request-1", Data: json.RawMessage(tt.data)})
+
+			var message game.WebSocketMessage
+			if err := json.Unmarshal(<-client.send, &message); err != nil || message.Type != game.MsgError {
+				t.Fatalf("got %s message: %v", message.Type, err)
+			}
+			var data game.ErrorData
+			if err := json.Unmarshal(message.Data, &data); err != nil {
+				t.Fatalf("decoding the error: %v", err)
+			}
+			if data.Code != game.CodeInvalidPayload || data.Field != tt.wantField || data.RequestID != "request-1" {
+				t.Fatalf("got %+v, want %s on %q", data, game.CodeInvalidPayload, tt.wantField)
+			}
+			if len(gm.ListGames()) != 0 {
+				t.Fatal("rejected message created a game")
+			}
+		})
+	}
+}
@@ -53,7 +53,7 @@ export const GameUI: React.FC = () => {
           <div key={player.id} className={`player ${player.isCurrentPlayer ? 'current' : ''}`}>
             <span>{player.name}</span>
             <span>Score: {player.score}</span>
-            <span>Cards: {player.hand.length}</span>
+            <span>Cards: {player.handCount ?? player.hand.length}</span>
           </div>
         ))}
       </div>
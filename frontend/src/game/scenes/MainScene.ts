@@ -228,6 +228,7 @@ export class MainScene extends Phaser.Scene {
       case 'diamonds': return '♦';
       case 'clubs': return '♣';
       case 'spades': return '♠';
+      case 'joker': return '★';
       default: return '?';
     }
   }
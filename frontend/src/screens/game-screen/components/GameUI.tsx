@@ -33,7 +33,7 @@ export const GameUI = ({ gameState, currentPlayer }: GameUIProps) => {
             className={`player-item ${player.isCurrentPlayer ? 'current-player' : ''}`}
           >
             <span className="player-name">{player.name}</span>
-            <span className="player-cards">Cards: {player.hand.length}</span>
+            <span className="player-cards">Cards: {player.handCount ?? player.hand.length}</span>
             <span className="player-score">Score: {player.score}</span>
           </div>
         ))}
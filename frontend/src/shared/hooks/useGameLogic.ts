@@ -22,17 +22,26 @@ export const useGameLogic = ({ wsUrl }: UseGameLogicProps) => {
 
   const handleWebSocketMessage = useCallback((message: any) => {
     switch (message.type) {
-      case 'game_state':
-        setGameState(prevState => ({
-          ...prevState,
-          gameId: message.gameId,
-          players: message.players,
-          currentPlayer: message.currentPlayer,
-          gamePhase: message.gamePhase,
-          playedCards: message.playedCards || [],
-          sharedZone: message.sharedZone || []
-        }));
+      case 'game_state': {
+        const game = message.data.game;
+        setGameState(prevState => {
+          // States may arrive out of order; an older one is dropped
+          if (game.id === prevState.gameId && game.version < (prevState.version ?? 0)) {
+            return prevState;
+          }
+          return {
+            ...prevState,
+            gameId: game.id,
+            players: game.players,
+            currentPlayer: game.currentPlayer,
+            gamePhase: game.gamePhase,
+            playedCards: game.playedCards || [],
+            sharedZone: game.sharedZone || [],
+            version: game.version
+          };
+        });
         break;
+      }
 
       case 'player_joined':
         setGameState(prevState => ({
@@ -113,7 +122,7 @@ export const useGameLogic = ({ wsUrl }: UseGameLogicProps) => {
   const playCard = useCallback((card: Card) => {
     sendMessage({
       type: 'play_card',
-      data: { card }
+      data: { cardId: card.id }
     });
     setSelectedCard(null);
   }, [sendMessage]);
@@ -122,7 +131,7 @@ export const useGameLogic = ({ wsUrl }: UseGameLogicProps) => {
     sendMessage({
       type: 'drop_card_shared',
       data: { 
-        card,
+        cardId: card.id,
         position: { x, y }
       }
     });
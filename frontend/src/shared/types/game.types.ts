@@ -1,6 +1,6 @@
 export interface Card {
   id: string;
-  suit: 'hearts' | 'diamonds' | 'clubs' | 'spades';
+  suit: 'hearts' | 'diamonds' | 'clubs' | 'spades' | 'oros' | 'copas' | 'espadas' | 'bastos' | 'joker';
   rank: string;
   value: number;
   x?: number;
@@ -13,6 +13,7 @@ export interface Player {
   id: string;
   name: string;
   hand: Card[];
+  handCount?: number;
   score: number;
   isCurrentPlayer: boolean;
   isDealer?: boolean;
@@ -22,14 +23,16 @@ export interface GameState {
   gameId: string | null;
   players: Player[];
   currentPlayer: string | null;
-  gamePhase: 'waiting' | 'playing' | 'finished';
+  gamePhase: 'waiting' | 'playing' | 'finished' | 'paused';
   playedCards: Card[];
   deck: Card[];
   sharedZone: Card[];
+  version?: number;
 }
 
 export interface WebSocketMessage {
   type: string;
+  requestId?: string;
   gameId?: string;
   playerId?: string;
   data?: any;
@@ -3,7 +3,7 @@ import { subscribeWithSelector } from 'zustand/middleware';
 
 export interface Card {
   id: string;
-  suit: 'hearts' | 'diamonds' | 'clubs' | 'spades';
+  suit: 'hearts' | 'diamonds' | 'clubs' | 'spades' | 'oros' | 'copas' | 'espadas' | 'bastos' | 'joker';
   rank: string;
   value: number;
 }
@@ -32,7 +32,8 @@ export interface GameState {
   gameId: string | null;
   players: Player[];
   currentPlayer: string | null;
-  gamePhase: 'waiting' | 'playing' | 'finished';
+  gamePhase: 'waiting' | 'playing' | 'finished' | 'paused';
+  version: number;
   
   // WebSocket connection
   ws: WebSocket | null;
@@ -52,7 +53,7 @@ export interface GameState {
   setGameId: (gameId: string) => void;
   setPlayers: (players: Player[]) => void;
   setCurrentPlayer: (playerId: string) => void;
-  setGamePhase: (phase: 'waiting' | 'playing' | 'finished') => void;
+  setGamePhase: (phase: 'waiting' | 'playing' | 'finished' | 'paused') => void;
   
   // WebSocket actions
   connectWebSocket: (url: string) => void;
@@ -79,6 +80,7 @@ export const useGameStore = create<GameState>()(
     players: [],
     currentPlayer: null,
     gamePhase: 'waiting',
+    version: 0,
     
     ws: null,
     connected: false,
@@ -162,7 +164,7 @@ export const useGameStore = create<GameState>()(
       const { sendMessage } = get();
       sendMessage({
         type: 'play_card',
-        card
+        data: { cardId: card.id }
       });
       set({ selectedCard: null });
     },
@@ -175,6 +177,7 @@ export const useGameStore = create<GameState>()(
       players: [],
       currentPlayer: null,
       gamePhase: 'waiting',
+      version: 0,
       selectedCard: null,
       showGame: false,
       currentScene: 'start'
@@ -185,14 +188,21 @@ export const useGameStore = create<GameState>()(
 // Handle incoming WebSocket messages
 function handleWebSocketMessage(message: any, set: any, get: any) {
   switch (message.type) {
-    case 'game_state':
+    case 'game_state': {
+      const game = message.data.game;
+      // States may arrive out of order; an older one is dropped
+      if (game.id === get().gameId && game.version < get().version) {
+        break;
+      }
       set({
-        gameId: message.gameId,
-        players: message.players,
-        currentPlayer: message.currentPlayer,
-        gamePhase: message.gamePhase
+        gameId: game.id,
+        players: game.players,
+        currentPlayer: game.currentPlayer,
+        gamePhase: game.gamePhase,
+        version: game.version
       });
       break;
+    }
       
     case 'player_joined':
       const { players } = get();